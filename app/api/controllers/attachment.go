@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/storage"
+	"finapp/services"
+)
+
+type AttachmentController struct {
+	logger  lib.Logger
+	service domains.AttachmentService
+}
+
+func NewAttachmentController(
+	logger lib.Logger,
+	service domains.AttachmentService,
+) AttachmentController {
+	return AttachmentController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary List of trx attachments
+// @tags attachment
+// @Description Получение списка вложений транзакции
+// @ID list_attachment
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID транзакции"
+// @Success 200 {array} models.AttachmentResponse
+// @Router /trx/{id}/attachments [get]
+func (ac AttachmentController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := ac.service.List(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to get list of attachments: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Upload trx attachment
+// @tags attachment
+// @Description Загрузка вложения (фото чека, PDF счёта) к транзакции.
+// @Description Размер и тип файла ограничены настройками ATTACHMENT_MAX_SIZE и ATTACHMENT_MIME_TYPES
+// @ID upload_attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param  id  path  int  true  "ID транзакции"
+// @Param file formData file true "Файл вложения"
+// @Success 200 {object} models.AttachmentResponse
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /trx/{id}/attachments [post]
+func (ac AttachmentController) Upload(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := ac.service.Upload(c, userID.(uint))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, services.ErrAttachmentTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, services.ErrAttachmentType):
+			status = http.StatusUnsupportedMediaType
+		case errors.Is(err, services.ErrBudgetForbidden):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to upload attachment: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Download trx attachment
+// @tags attachment
+// @Description Скачивание вложения транзакции
+// @ID download_attachment
+// @Produce octet-stream
+// @Param  id  path  int  true  "ID транзакции"
+// @Param  attachment_id  path  int  true  "ID вложения"
+// @Success 200 {file} file
+// @Router /trx/{id}/attachments/{attachment_id} [get]
+func (ac AttachmentController) Download(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	attachment, file, err := ac.service.Download(c, userID.(uint))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to download attachment: %s", err.Error()),
+		})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{
+			"filename": attachment.FileName,
+		}),
+	})
+}
+
+// @Security ApiKeyAuth
+// @summary Delete trx attachment
+// @tags attachment
+// @Description Удаление вложения транзакции вместе с файлом
+// @ID delete_attachment
+// @Produce json
+// @Param  id  path  int  true  "ID транзакции"
+// @Param  attachment_id  path  int  true  "ID вложения"
+// @Router /trx/{id}/attachments/{attachment_id} [delete]
+func (ac AttachmentController) Delete(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	if err := ac.service.Delete(c, userID.(uint)); err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to delete attachment: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "attachment was deleted",
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"finapp/constants"
 	"finapp/domains"
@@ -40,6 +41,9 @@ func NewBudgetController(
 // @Param        id   path      int  true  "ID бюджета"
 // @Param date_from query string false "Дата начала периода в формате 18-10-2004"
 // @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
+// @Param currency query string false "Валюта сумм (ISO 4217), пересчёт по курсам на дату"
+// @Param limit_periods query int false "Число прошедших периодов лимита, по умолчанию 12"
+// @Param include_children query bool false "Суммировать с суммами всех вложенных бюджетов"
 // @Success 200 {object} models.BudgetGetResponse
 // @Router /budget/{id} [get]
 func (bc BudgetController) Get(c *gin.Context) {
@@ -75,7 +79,15 @@ func (bc BudgetController) Get(c *gin.Context) {
 // @Produce json
 // @Param date_from query string false "Дата начала периода в формате 18-10-2004"
 // @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
-// @Success 200 {array} models.BudgetGetResponse
+// @Param currency query string false "Валюта сумм (ISO 4217), пересчёт по курсам на дату"
+// @Param limit_periods query int false "Число прошедших периодов лимита, по умолчанию 12"
+// @Param include_children query bool false "Суммировать с суммами всех вложенных бюджетов"
+// @Param archived query bool false "Показывать архивные бюджеты"
+// @Param limit query int false "Размер страницы, по умолчанию 50, максимум 500"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поле сортировки: created_at, title. По умолчанию created_at"
+// @Param order query string false "Порядок сортировки: asc, desc. По умолчанию desc"
+// @Success 200 {object} models.PageResponse[models.BudgetGetResponse]
 // @Router /budget [get]
 func (bc BudgetController) List(c *gin.Context) {
 	userID, ok := c.Get(constants.UserID)
@@ -96,11 +108,41 @@ func (bc BudgetController) List(c *gin.Context) {
 		return
 	}
 
-	if budgets == nil {
-		budgets = make([]models.BudgetGetResponse, 0)
+	c.JSON(http.StatusOK, budgets)
+}
+
+// Дерево
+
+// @Security ApiKeyAuth
+// @summary Budget tree
+// @tags budget
+// @Description Дерево бюджетов с остатками на дату, total узла включает все вложенные бюджеты
+// @ID budget-tree
+// @Accept json
+// @Produce json
+// @Param date query string false "Дата остатков в формате 18-10-2004, по умолчанию сегодня"
+// @Param currency query string false "Валюта сумм (ISO 4217), пересчёт по курсам на дату"
+// @Success 200 {array} models.BudgetTreeResponse
+// @Router /budget/tree [get]
+func (bc BudgetController) Tree(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, budgets)
+	tree, err := bc.service.Tree(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":       "Failed to get budget tree",
+			"description": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
 }
 
 // Создание
@@ -140,8 +182,14 @@ func (bc BudgetController) Post(c *gin.Context) {
 		return
 	}
 
-	resp, err := bc.service.Create(&budget, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := bc.service.WithTrx(txHandle).Create(&budget, userID.(uint))
 	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -188,8 +236,14 @@ func (bc BudgetController) Patch(c *gin.Context) {
 		return
 	}
 
-	newBudget, err := bc.service.Patch(c, budget, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	newBudget, err := bc.service.WithTrx(txHandle).Patch(c, budget, userID.(uint))
 	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to update budget: %s", err.Error()),
 		})
@@ -219,7 +273,13 @@ func (bc BudgetController) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := bc.service.Delete(c, userID.(uint)); err != nil {
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := bc.service.WithTrx(txHandle).Delete(c, userID.(uint)); err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to delete budget: %s", err.Error()),
 		})
@@ -230,3 +290,196 @@ func (bc BudgetController) Delete(c *gin.Context) {
 		"message": "budget deleted successfully",
 	})
 }
+
+// Сверка
+
+// @Security ApiKeyAuth
+// @summary Reconcile budget
+// @tags budget
+// @Description Сверка бюджета с выпиской банка. Остаток бюджета на дату выписки без транзакций в статусе pending
+// @Description сравнивается с остатком выписки. Если они совпали, прошедшие по счёту транзакции до этой даты
+// @Description отмечаются сверенными и блокируются от изменений, иначе возвращается 422 с расхождением
+// @ID budget-reconcile
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Param statement body models.BudgetReconcileRequest true "Дата и остаток выписки"
+// @Success 200 {object} models.BudgetReconcileResponse
+// @Failure 422 {object} models.BudgetReconcileResponse
+// @Router /budget/{id}/reconcile [post]
+func (bc BudgetController) Reconcile(c *gin.Context) {
+	var request models.BudgetReconcileRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := bc.service.WithTrx(txHandle).Reconcile(c, request, userID.(uint))
+	if err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to reconcile budget: %s", err.Error()),
+		})
+		return
+	}
+
+	if !resp.Reconciled {
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Архивация
+
+// @Security ApiKeyAuth
+// @summary Archive budget
+// @tags budget
+// @Description Архивация бюджета: он скрывается из списка бюджетов, но учитывается в отчётах
+// @ID budget-archive
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Success 200 {object} models.BudgetArchiveResponse
+// @Router /budget/{id}/archive [post]
+func (bc BudgetController) Archive(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := bc.service.WithTrx(txHandle).Archive(c, userID.(uint))
+	if err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to archive budget: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Unarchive budget
+// @tags budget
+// @Description Возврат бюджета из архива
+// @ID budget-unarchive
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Success 200 {object} models.BudgetArchiveResponse
+// @Router /budget/{id}/unarchive [post]
+func (bc BudgetController) Unarchive(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := bc.service.WithTrx(txHandle).Unarchive(c, userID.(uint))
+	if err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to unarchive budget: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Закрытие
+
+// @Security ApiKeyAuth
+// @summary Close budget
+// @tags budget
+// @Description Закрытие бюджета: остаток на дату закрытия переводится в другой бюджет,
+// @Description генераторы бюджета останавливаются, сам бюджет архивируется
+// @ID budget-close
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Param close body models.BudgetCloseRequest true "Бюджет для остатка и дата закрытия"
+// @Success 200 {object} models.BudgetCloseResponse
+// @Router /budget/{id}/close [post]
+func (bc BudgetController) Close(c *gin.Context) {
+	var request models.BudgetCloseRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := bc.service.WithTrx(txHandle).Close(c, request, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithArchivedError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to close budget: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/validators"
+	"finapp/models"
+	"finapp/services"
+)
+
+type BudgetMemberController struct {
+	logger  lib.Logger
+	service domains.BudgetMemberService
+}
+
+func NewBudgetMemberController(
+	logger lib.Logger,
+	service domains.BudgetMemberService,
+) BudgetMemberController {
+	return BudgetMemberController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary List budget members
+// @tags budget
+// @Description Участники бюджета и непринятые приглашения в него
+// @ID budget-members-list
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Success 200 {array} models.BudgetMemberResponse
+// @Router /budget/{id}/members [get]
+func (mc BudgetMemberController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	members, err := mc.service.List(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to get budget members: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// @Security ApiKeyAuth
+// @summary List budget invitations
+// @tags budget
+// @Description Непринятые приглашения пользователя в общие бюджеты
+// @ID budget-invitations
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.BudgetMemberResponse
+// @Router /budget/invitations [get]
+func (mc BudgetMemberController) Invitations(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	invitations, err := mc.service.Invitations(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get invitations: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// @Security ApiKeyAuth
+// @summary Invite budget member
+// @tags budget
+// @Description Приглашение пользователя в бюджет по почте, доступно владельцам бюджета.
+// @Description Приглашение участника меняет его роль
+// @ID budget-members-invite
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Param invite body models.BudgetInviteRequest true "Почта и роль участника"
+// @Success 200 {object} models.BudgetMemberResponse
+// @Router /budget/{id}/members [post]
+func (mc BudgetMemberController) Invite(c *gin.Context) {
+	var request models.BudgetInviteRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	member, err := mc.service.WithTrx(txHandle).Invite(c, request, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to invite budget member: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// @Security ApiKeyAuth
+// @summary Accept budget invitation
+// @tags budget
+// @Description Принятие приглашения в бюджет
+// @ID budget-members-accept
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Success 200 {object} models.BudgetMemberResponse
+// @Router /budget/{id}/members/accept [post]
+func (mc BudgetMemberController) Accept(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	member, err := mc.service.WithTrx(txHandle).Accept(c, userID.(uint))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to accept invitation: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// @Security ApiKeyAuth
+// @summary Remove budget member
+// @tags budget
+// @Description Исключение участника владельцем бюджета, выход из бюджета или отказ от приглашения.
+// @Description Создателя бюджета исключить нельзя
+// @ID budget-members-remove
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID бюджета"
+// @Param   user_id   path      int  true  "ID участника"
+// @Router /budget/{id}/members/{user_id} [delete]
+func (mc BudgetMemberController) Remove(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := mc.service.WithTrx(txHandle).Remove(c, userID.(uint)); err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrBudgetCreator) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to remove budget member: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "budget member removed successfully",
+	})
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/validators"
+	"finapp/models"
+)
+
+type CategoryController struct {
+	logger  lib.Logger
+	service domains.CategoryService
+}
+
+func NewCategoryController(
+	logger lib.Logger,
+	service domains.CategoryService,
+) CategoryController {
+	return CategoryController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary Create category
+// @tags category
+// @Description Создание категории транзакций
+// @ID post_category
+// @Accept json
+// @Produce json
+// @Param category body models.CategoryStoreRequest true "Данные категории"
+// @Success 200 {object} models.CategoryResponse
+// @Router /trx/category [post]
+func (cc CategoryController) Store(c *gin.Context) {
+	var category models.CategoryStoreRequest
+
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(category); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := cc.service.Store(&category, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to store category: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary List of categories
+// @tags category
+// @Description Получение категорий транзакций
+// @ID list_category
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.CategoryResponse
+// @Router /trx/category [get]
+func (cc CategoryController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := cc.service.List(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get list of categories: %s", err.Error()),
+		})
+		return
+	}
+
+	if resp == nil {
+		resp = make([]models.CategoryResponse, 0)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Get category
+// @tags category
+// @Description Получение категории транзакций
+// @ID get_category
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID категории"
+// @Success 200 {object} models.CategoryResponse
+// @Router /trx/category/{id} [get]
+func (cc CategoryController) Get(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := cc.service.Get(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get category: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Update category
+// @tags category
+// @Description Изменение категории транзакций
+// @ID patch_category
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID категории"
+// @Param category body models.CategoryPatchRequest true "Данные категории"
+// @Success 200 {object} models.CategoryResponse
+// @Router /trx/category/{id} [patch]
+func (cc CategoryController) Patch(c *gin.Context) {
+	var category models.CategoryPatchRequest
+
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(category); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := cc.service.Patch(c, category, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to update category: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Delete category
+// @tags category
+// @Description Удаление категории транзакций
+// @ID delete_category
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID категории"
+// @Router /trx/category/{id} [delete]
+func (cc CategoryController) Delete(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	if err := cc.service.Delete(c, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to delete category: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "category was deleted",
+	})
+}
@@ -10,4 +10,13 @@ var Module = fx.Options(
 	fx.Provide(NewBudgetController),
 	fx.Provide(NewTrxController),
 	fx.Provide(NewGeneratorController),
+	fx.Provide(NewCategoryController),
+	fx.Provide(NewTagController),
+	fx.Provide(NewExchangeRateController),
+	fx.Provide(NewAttachmentController),
+	fx.Provide(NewPayeeController),
+	fx.Provide(NewRuleController),
+	fx.Provide(NewTrashController),
+	fx.Provide(NewHistoryController),
+	fx.Provide(NewBudgetMemberController),
 )
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/models"
+	"finapp/services"
+)
+
+// Отвечает 422 на ссылки на чужие или удалённые бюджеты и цели,
+// в том же виде, что и ошибки валидации запроса
+func abortWithReferenceError(c *gin.Context, err error) bool {
+	var refErr services.ReferenceError
+	if !errors.As(err, &refErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error": refErr,
+	})
+	return true
+}
+
+// Отвечает 409 со списком похожих транзакций, если создаваемая транзакция похожа на дубликат
+func abortWithDuplicateError(c *gin.Context, err error) bool {
+	var dupErr services.DuplicateError
+	if !errors.As(err, &dupErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":      dupErr.Error(),
+		"duplicates": dupErr.Duplicates,
+	})
+	return true
+}
+
+// Отвечает 409 на попытку изменить сверенную транзакцию без разблокировки
+func abortWithReconciledError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrTrxReconciled) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error": err.Error(),
+	})
+	return true
+}
+
+// Отвечает 409 на закрытие уже архивного бюджета
+func abortWithArchivedError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrBudgetArchived) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error": err.Error(),
+	})
+	return true
+}
+
+// Отвечает 403 на действия с общим бюджетом, недоступные роли пользователя
+func abortWithForbiddenError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrBudgetForbidden) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": err.Error(),
+	})
+	return true
+}
+
+// Отвечает 422 на транзакцию без бюджета списания и зачисления
+func abortWithNoBudgetError(c *gin.Context, err error) bool {
+	if !errors.Is(err, models.ErrTrxNoBudget) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error": err.Error(),
+	})
+	return true
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/validators"
+	"finapp/models"
+)
+
+type ExchangeRateController struct {
+	logger  lib.Logger
+	service domains.ExchangeRateService
+}
+
+func NewExchangeRateController(
+	logger lib.Logger,
+	service domains.ExchangeRateService,
+) ExchangeRateController {
+	return ExchangeRateController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary List of exchange rates
+// @tags exchange_rate
+// @Description Получение курсов валют
+// @ID list_exchange_rate
+// @Accept json
+// @Produce json
+// @Param date_from query string false "Дата начала периода в формате 18-10-2004"
+// @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
+// @Success 200 {array} models.ExchangeRateResponse
+// @Router /exchange-rate [get]
+func (ec ExchangeRateController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := ec.service.List(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to get list of exchange rates: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Import exchange rates
+// @tags exchange_rate
+// @Description Загрузка курсов валют. Курс на ту же дату и пару валют перезаписывается.
+// @Description Для пересчёта используется последний курс не позже даты суммы, прямой или обратный
+// @ID import_exchange_rate
+// @Accept json
+// @Produce json
+// @Param rates body models.ExchangeRateImportRequest true "Курсы валют"
+// @Success 200 {object} models.ExchangeRateImportResponse
+// @Router /exchange-rate/import [post]
+func (ec ExchangeRateController) Import(c *gin.Context) {
+	var request models.ExchangeRateImportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := ec.service.Import(request, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to import exchange rates: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -11,6 +11,7 @@ import (
 	"finapp/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type GeneratorController struct {
@@ -63,8 +64,14 @@ func (gc GeneratorController) Store(c *gin.Context) {
 		return
 	}
 
-	resp, err := gc.service.Store(generator, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := gc.service.WithTrx(txHandle).Store(generator, userID.(uint))
 	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to store generator: %s", err.Error()),
 		})
@@ -81,7 +88,11 @@ func (gc GeneratorController) Store(c *gin.Context) {
 // @ID list_gen
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.GeneratorResponse
+// @Param limit query int false "Размер страницы, по умолчанию 50, максимум 500"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поле сортировки: created_at, date_from, amount, title. По умолчанию created_at"
+// @Param order query string false "Порядок сортировки: asc, desc. По умолчанию desc"
+// @Success 200 {object} models.PageResponse[models.GeneratorResponse]
 // @Router /trx/generator [get]
 func (gc GeneratorController) List(c *gin.Context) {
 	userID, ok := c.Get(constants.UserID)
@@ -92,7 +103,7 @@ func (gc GeneratorController) List(c *gin.Context) {
 		return
 	}
 
-	resp, err := gc.service.List(userID.(uint))
+	resp, err := gc.service.List(c, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to get list of generators: %s", err.Error()),
@@ -100,10 +111,6 @@ func (gc GeneratorController) List(c *gin.Context) {
 		return
 	}
 
-	if resp == nil {
-		resp = make([]models.GeneratorResponse, 0)
-	}
-
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -173,8 +180,14 @@ func (gc GeneratorController) Update(c *gin.Context) {
 		return
 	}
 
-	resp, err := gc.service.Update(c, generator, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := gc.service.WithTrx(txHandle).Update(c, generator, userID.(uint))
 	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to update generator: %s", err.Error()),
 		})
@@ -202,7 +215,13 @@ func (gc GeneratorController) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := gc.service.Delete(c, userID.(uint)); err != nil {
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := gc.service.WithTrx(txHandle).Delete(c, userID.(uint)); err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to delete generator: %s", err.Error()),
 		})
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"finapp/constants"
 	"finapp/domains"
@@ -39,7 +40,12 @@ func NewGoalController(
 // @Produce json
 // @Param date_from query string false "Дата начала периода в формате 18-10-2004"
 // @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
-// @Success 200 {array} models.GoalCalcResponse
+// @Param currency query string false "Валюта сумм (ISO 4217), пересчёт по курсам на дату"
+// @Param limit query int false "Размер страницы, по умолчанию 50, максимум 500"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поле сортировки: created_at, target_amount, title. По умолчанию created_at"
+// @Param order query string false "Порядок сортировки: asc, desc. По умолчанию desc"
+// @Success 200 {object} models.PageResponse[models.GoalCalcResponse]
 // @Router /goal [get]
 func (gc GoalController) List(c *gin.Context) {
 	userID, ok := c.Get(constants.UserID)
@@ -60,10 +66,6 @@ func (gc GoalController) List(c *gin.Context) {
 		return
 	}
 
-	if goals == nil {
-		goals = make([]models.GoalCalcResponse, 0)
-	}
-
 	c.JSON(http.StatusOK, goals)
 }
 
@@ -77,6 +79,7 @@ func (gc GoalController) List(c *gin.Context) {
 // @Param id path integer false "id цели"
 // @Param date_from query string false "Дата начала периода в формате 18-10-2004"
 // @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
+// @Param currency query string false "Валюта сумм (ISO 4217), пересчёт по курсам на дату"
 // @Success 200 {object} models.GoalResponse
 // @Router /goal/{id} [get]
 func (gc GoalController) Get(c *gin.Context) {
@@ -136,7 +139,10 @@ func (gc GoalController) Store(c *gin.Context) {
 		return
 	}
 
-	resp, err := gc.service.Store(&goal, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := gc.service.WithTrx(txHandle).Store(&goal, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -185,7 +191,10 @@ func (gc GoalController) Update(c *gin.Context) {
 		return
 	}
 
-	resp, err := gc.service.Update(c, goal, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := gc.service.WithTrx(txHandle).Update(c, goal, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to update goal: %s", err.Error()),
@@ -216,7 +225,10 @@ func (gc GoalController) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := gc.service.Delete(c, userID.(uint)); err != nil {
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := gc.service.WithTrx(txHandle).Delete(c, userID.(uint)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to delete goal: %s", err.Error()),
 		})
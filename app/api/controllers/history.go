@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+)
+
+type HistoryController struct {
+	logger  lib.Logger
+	service domains.HistoryService
+}
+
+func NewHistoryController(
+	logger lib.Logger,
+	service domains.HistoryService,
+) HistoryController {
+	return HistoryController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary List record history
+// @tags history
+// @Description Версии записи, сначала последние. before и after - значения колонок записи
+// @Description до и после изменения, для транзакции также теги (tags) и части (legs)
+// @ID list_history
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID записи"
+// @Success 200 {array} models.HistoryResponse
+// @Router /trx/{id}/history [get]
+// @Router /budget/{id}/history [get]
+// @Router /goal/{id}/history [get]
+// @Router /generator/{id}/history [get]
+// @Router /trx/generator/{id}/history [get]
+func (hc HistoryController) List(entity models.HistoryEntity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get(constants.UserID)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get user",
+			})
+			return
+		}
+
+		resp, err := hc.service.List(c, entity, userID.(uint))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("failed to get history: %s", err.Error()),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary Revert record to version
+// @tags history
+// @Description Возвращает запись к состоянию после изменения выбранной версии.
+// @Description Возврат к версии удаления удаляет запись, удалённая запись восстанавливается.
+// @Description Сверенную транзакцию нужно сначала разблокировать
+// @ID revert_history
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID записи"
+// @Param  version  path  int  true  "Номер версии"
+// @Success 200 {object} models.HistoryResponse
+// @Router /trx/{id}/history/{version}/revert [post]
+// @Router /budget/{id}/history/{version}/revert [post]
+// @Router /goal/{id}/history/{version}/revert [post]
+// @Router /generator/{id}/history/{version}/revert [post]
+// @Router /trx/generator/{id}/history/{version}/revert [post]
+func (hc HistoryController) Revert(entity models.HistoryEntity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get(constants.UserID)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get user",
+			})
+			return
+		}
+
+		trxHandle, _ := c.Get(constants.DBTransaction)
+		txHandle, _ := trxHandle.(*gorm.DB)
+
+		resp, err := hc.service.WithTrx(txHandle).Revert(c, entity, userID.(uint))
+		if err != nil {
+			if abortWithReconciledError(c, err) {
+				return
+			}
+			status := http.StatusBadRequest
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{
+				"error": fmt.Sprintf("failed to revert: %s", err.Error()),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
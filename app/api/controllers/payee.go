@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/validators"
+	"finapp/models"
+)
+
+type PayeeController struct {
+	logger  lib.Logger
+	service domains.PayeeService
+}
+
+func NewPayeeController(
+	logger lib.Logger,
+	service domains.PayeeService,
+) PayeeController {
+	return PayeeController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary Create payee
+// @tags payee
+// @Description Создание получателя платежа. Категория и бюджет получателя подставляются в его транзакции, если они не заданы
+// @ID post_payee
+// @Accept json
+// @Produce json
+// @Param payee body models.PayeeStoreRequest true "Данные получателя"
+// @Success 200 {object} models.PayeeResponse
+// @Router /trx/payee [post]
+func (pc PayeeController) Store(c *gin.Context) {
+	var payee models.PayeeStoreRequest
+
+	if err := c.ShouldBindJSON(&payee); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(payee); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := pc.service.Store(&payee, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to store payee: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary List of payees
+// @tags payee
+// @Description Получение получателей платежей
+// @ID list_payee
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.PayeeResponse
+// @Router /trx/payee [get]
+func (pc PayeeController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := pc.service.List(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get list of payees: %s", err.Error()),
+		})
+		return
+	}
+
+	if resp == nil {
+		resp = make([]models.PayeeResponse, 0)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Get payee
+// @tags payee
+// @Description Получение получателя платежа
+// @ID get_payee
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID получателя"
+// @Success 200 {object} models.PayeeResponse
+// @Router /trx/payee/{id} [get]
+func (pc PayeeController) Get(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := pc.service.Get(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get payee: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Update payee
+// @tags payee
+// @Description Изменение получателя платежа. Категорию и бюджет можно сбросить явным null
+// @ID patch_payee
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID получателя"
+// @Param payee body models.PayeePatchRequest true "Данные получателя"
+// @Success 200 {object} models.PayeeResponse
+// @Router /trx/payee/{id} [patch]
+func (pc PayeeController) Patch(c *gin.Context) {
+	var payee models.PayeePatchRequest
+
+	if err := c.ShouldBindJSON(&payee); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(payee); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := pc.service.Patch(c, payee, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to update payee: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Delete payee
+// @tags payee
+// @Description Удаление получателя платежа. Транзакции и правила отвязываются от него
+// @ID delete_payee
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID получателя"
+// @Router /trx/payee/{id} [delete]
+func (pc PayeeController) Delete(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	if err := pc.service.Delete(c, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to delete payee: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "payee was deleted",
+	})
+}
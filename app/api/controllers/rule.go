@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/validators"
+	"finapp/models"
+)
+
+type RuleController struct {
+	logger  lib.Logger
+	service domains.RuleService
+}
+
+func NewRuleController(
+	logger lib.Logger,
+	service domains.RuleService,
+) RuleController {
+	return RuleController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary Create rule
+// @tags rule
+// @Description Создание правила категоризации. Правила применяются к новым и импортированным транзакциям по возрастанию priority
+// @ID post_rule
+// @Accept json
+// @Produce json
+// @Param rule body models.RuleRequest true "Данные правила"
+// @Success 200 {object} models.RuleResponse
+// @Router /trx/rule [post]
+func (rc RuleController) Store(c *gin.Context) {
+	var rule models.RuleRequest
+
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(rule); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := rc.service.Store(&rule, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to store rule: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary List of rules
+// @tags rule
+// @Description Получение правил категоризации в порядке применения
+// @ID list_rule
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.RuleResponse
+// @Router /trx/rule [get]
+func (rc RuleController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := rc.service.List(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get list of rules: %s", err.Error()),
+		})
+		return
+	}
+
+	if resp == nil {
+		resp = make([]models.RuleResponse, 0)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Get rule
+// @tags rule
+// @Description Получение правила категоризации
+// @ID get_rule
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID правила"
+// @Success 200 {object} models.RuleResponse
+// @Router /trx/rule/{id} [get]
+func (rc RuleController) Get(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := rc.service.Get(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get rule: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Replace rule
+// @tags rule
+// @Description Замена правила категоризации целиком
+// @ID put_rule
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID правила"
+// @Param rule body models.RuleRequest true "Данные правила"
+// @Success 200 {object} models.RuleResponse
+// @Router /trx/rule/{id} [put]
+func (rc RuleController) Replace(c *gin.Context) {
+	var rule models.RuleRequest
+
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(rule); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := rc.service.Replace(c, &rule, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to replace rule: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Delete rule
+// @tags rule
+// @Description Удаление правила категоризации
+// @ID delete_rule
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID правила"
+// @Router /trx/rule/{id} [delete]
+func (rc RuleController) Delete(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	if err := rc.service.Delete(c, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to delete rule: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "rule was deleted",
+	})
+}
+
+// @Security ApiKeyAuth
+// @summary Apply rules
+// @tags rule
+// @Description Применение правил к существующим транзакциям, отобранным фильтрами списка транзакций.
+// @Description Без overwrite заполняются только пустые получатель и категория
+// @ID apply_rule
+// @Accept json
+// @Produce json
+// @Param request body models.RuleApplyRequest true "Параметры применения"
+// @Param amount_min query number false "Минимальная сумма"
+// @Param amount_max query number false "Максимальная сумма"
+// @Param date_from query string false "Дата начала периода в формате 18-10-2004"
+// @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
+// @Param category_id query string false "ID категорий через запятую, null - без категории"
+// @Param tags query string false "Теги: any:a,b - любой из тегов, all:a,b - все теги"
+// @Param q query string false "Поиск по названию и заметке"
+// @Success 200 {object} models.RuleApplyResponse
+// @Router /trx/rule/apply [post]
+func (rc RuleController) Apply(c *gin.Context) {
+	var request models.RuleApplyRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := rc.service.WithTrx(txHandle).Apply(c, request, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to apply rules: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/validators"
+	"finapp/models"
+)
+
+type TagController struct {
+	logger  lib.Logger
+	service domains.TagService
+}
+
+func NewTagController(
+	logger lib.Logger,
+	service domains.TagService,
+) TagController {
+	return TagController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary List of tags
+// @tags tag
+// @Description Получение тегов транзакций
+// @ID list_tag
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.TagResponse
+// @Router /trx/tag [get]
+func (tc TagController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := tc.service.List(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to get list of tags: %s", err.Error()),
+		})
+		return
+	}
+
+	if resp == nil {
+		resp = make([]models.TagResponse, 0)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Rename tag
+// @tags tag
+// @Description Переименование тега
+// @ID patch_tag
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID тега"
+// @Param tag body models.TagRenameRequest true "Новое название"
+// @Success 200 {object} models.TagResponse
+// @Router /trx/tag/{id} [patch]
+func (tc TagController) Rename(c *gin.Context) {
+	var tag models.TagRenameRequest
+
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(tag); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := tc.service.Rename(c, tag, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to rename tag: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Merge tags
+// @tags tag
+// @Description Слияние тега с другим тегом. Транзакции переносятся на целевой тег, исходный удаляется
+// @ID merge_tag
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID исходного тега"
+// @Param tag body models.TagMergeRequest true "Целевой тег"
+// @Success 200 {object} models.TagResponse
+// @Router /trx/tag/{id}/merge [post]
+func (tc TagController) Merge(c *gin.Context) {
+	var merge models.TagMergeRequest
+
+	if err := c.ShouldBindJSON(&merge); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(merge); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := tc.service.Merge(c, merge, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to merge tags: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Delete tag
+// @tags tag
+// @Description Удаление тега
+// @ID delete_tag
+// @Accept json
+// @Produce json
+// @Param  id  path  int  true  "ID тега"
+// @Router /trx/tag/{id} [delete]
+func (tc TagController) Delete(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	if err := tc.service.Delete(c, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to delete tag: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "tag was deleted",
+	})
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/services"
+)
+
+type TrashController struct {
+	logger  lib.Logger
+	service domains.TrashService
+}
+
+func NewTrashController(
+	logger lib.Logger,
+	service domains.TrashService,
+) TrashController {
+	return TrashController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// @Security ApiKeyAuth
+// @summary List trash
+// @tags trash
+// @Description Удалённые транзакции, бюджеты, цели и генераторы, сначала недавно удалённые.
+// @Description Записи хранятся в корзине ограниченное время, затем удаляются окончательно
+// @ID list_trash
+// @Accept json
+// @Produce json
+// @Param entity query string false "Тип записей: trx, budget, goal, generator"
+// @Success 200 {array} models.TrashItemResponse
+// @Router /trash [get]
+func (tc TrashController) List(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := tc.service.List(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to get trash: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Restore from trash
+// @tags trash
+// @Description Восстановление удалённой записи. Транзакция восстанавливается вместе с частями и вложениями
+// @ID restore_trash
+// @Accept json
+// @Produce json
+// @Param  entity  path  string  true  "Тип записи: trx, budget, goal, generator"
+// @Param  id  path  int  true  "ID записи"
+// @Router /trash/{entity}/{id}/restore [post]
+func (tc TrashController) Restore(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := tc.service.WithTrx(txHandle).Restore(c, userID.(uint)); err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrBudgetInUse):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to restore: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "restored",
+	})
+}
+
+// @Security ApiKeyAuth
+// @summary Purge from trash
+// @tags trash
+// @Description Окончательное удаление записи из корзины. Бюджет, на который ещё ссылаются
+// @Description транзакции или генераторы, не удаляется: ответ 409
+// @ID purge_trash
+// @Accept json
+// @Produce json
+// @Param  entity  path  string  true  "Тип записи: trx, budget, goal, generator"
+// @Param  id  path  int  true  "ID записи"
+// @Router /trash/{entity}/{id} [delete]
+func (tc TrashController) Purge(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := tc.service.WithTrx(txHandle).Purge(c, userID.(uint)); err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrBudgetInUse):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error": fmt.Sprintf("failed to purge: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "purged",
+	})
+}
+
+// @Security ApiKeyAuth
+// @summary Empty trash
+// @tags trash
+// @Description Окончательное удаление всех записей из корзины
+// @ID empty_trash
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.TrashPurgeResponse
+// @Router /trash [delete]
+func (tc TrashController) Empty(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := tc.service.WithTrx(txHandle).Empty(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to empty trash: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"finapp/constants"
 	"finapp/domains"
 	"finapp/lib"
+	"finapp/lib/export"
 	"finapp/models"
 )
 
@@ -41,7 +43,16 @@ func NewTrxController(
 // @Param amount_max query number false "Максимальная сумма"
 // @Param date_from query string false "Дата начала периода в формате 18-10-2004"
 // @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
-// @Success 200 {array} models.TrxResponse
+// @Param category_id query string false "ID категорий через запятую, null - без категории"
+// @Param tags query string false "Теги: any:a,b - любой из тегов, all:a,b - все теги"
+// @Param status query string false "Статусы через запятую: pending, cleared, reconciled"
+// @Param type query string false "Виды через запятую: income, expense, transfer, split"
+// @Param q query string false "Поиск по названию и заметке"
+// @Param limit query int false "Размер страницы, по умолчанию 50, максимум 500"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поле сортировки: date, amount, created_at, relevance (только с q). По умолчанию date, с q - relevance"
+// @Param order query string false "Порядок сортировки: asc, desc. По умолчанию desc"
+// @Success 200 {object} models.PageResponse[models.TrxResponse]
 // @Router /trx [get]
 func (tc TrxController) List(c *gin.Context) {
 	userID, ok := c.Get(constants.UserID)
@@ -61,18 +72,7 @@ func (tc TrxController) List(c *gin.Context) {
 		return
 	}
 
-	trxResponses := make([]models.TrxResponse, 0)
-	for _, trx := range trxs {
-		trxResponses = append(trxResponses, models.TrxResponse{
-			ID:         trx.ID,
-			Title:      trx.Title,
-			Date:       trx.Date,
-			Amount:     trx.Amount,
-			BudgetFrom: trx.BudgetFrom,
-			BudgetTo:   trx.BudgetTo,
-		})
-	}
-	c.JSON(http.StatusOK, trxResponses)
+	c.JSON(http.StatusOK, trxs)
 }
 
 // @Security ApiKeyAuth
@@ -110,7 +110,8 @@ func (tc TrxController) Get(c *gin.Context) {
 // @Security ApiKeyAuth
 // @summary Create trx
 // @tags trx
-// @Description Создание транзакции
+// @Description Создание транзакции. С check_duplicates=true при наличии похожей транзакции
+// @Description она не создаётся, а возвращается 409 со списком похожих
 // @ID post_trx
 // @Accept json
 // @Produce json
@@ -142,8 +143,14 @@ func (tc TrxController) Post(c *gin.Context) {
 		return
 	}
 
-	trx, err := tc.service.Create(&transaction, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	trx, err := tc.service.WithTrx(txHandle).Create(&transaction, userID.(uint))
 	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithNoBudgetError(c, err) || abortWithDuplicateError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("failed to create trx: %s", err.Error()),
 		})
@@ -189,8 +196,14 @@ func (tc TrxController) Patch(c *gin.Context) {
 		return
 	}
 
-	trxResponse, err := tc.service.Patch(c, transaction, userID.(uint))
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	trxResponse, err := tc.service.WithTrx(txHandle).Patch(c, transaction, userID.(uint))
 	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithNoBudgetError(c, err) || abortWithReconciledError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to update trx: %s", err.Error()),
 		})
@@ -220,7 +233,13 @@ func (tc TrxController) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := tc.service.Delete(c, userID.(uint)); err != nil {
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	if err := tc.service.WithTrx(txHandle).Delete(c, userID.(uint)); err != nil {
+		if abortWithReconciledError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to delete trx: %s", err.Error()),
 		})
@@ -231,3 +250,426 @@ func (tc TrxController) Delete(c *gin.Context) {
 		"message": "transaction was deleted",
 	})
 }
+
+// Разделение
+
+// @Security ApiKeyAuth
+// @summary Split trx
+// @tags trx
+// @Description Разделение транзакции на части со своими бюджетами. Сумма частей должна совпадать
+// @Description с суммой транзакции, существующие части заменяются
+// @ID split_trx
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID транзакции"
+// @Param legs body models.TrxSplitRequest true "Части транзакции"
+// @Success 200 {object} models.TrxResponse
+// @Router /trx/{id}/split [put]
+func (tc TrxController) Split(c *gin.Context) {
+	var request models.TrxSplitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := tc.service.WithTrx(txHandle).Split(c, request, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) || abortWithNoBudgetError(c, err) || abortWithReconciledError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to split trx: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Разблокировка
+
+// @Security ApiKeyAuth
+// @summary Unlock trx
+// @tags trx
+// @Description Разблокировка сверенной транзакции для изменения, статус меняется на cleared
+// @ID unlock_trx
+// @Accept json
+// @Produce json
+// @Param        id   path      int  true  "ID транзакции"
+// @Success 200 {object} models.TrxResponse
+// @Router /trx/{id}/unlock [post]
+func (tc TrxController) Unlock(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := tc.service.WithTrx(txHandle).Unlock(c, userID.(uint))
+	if err != nil {
+		if abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to unlock trx: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Пакетные операции
+
+// @Security ApiKeyAuth
+// @summary Bulk trx operations
+// @tags trx
+// @Description Пакетное создание, изменение и удаление транзакций. Операции применяются по порядку
+// @Description в одной транзакции БД: при ошибке любой из них не применяется ни одна.
+// @Description data операции create - TrxRequest, patch - TrxPatchRequest
+// @ID bulk_trx
+// @Accept json
+// @Produce json
+// @Param operations body models.TrxBulkRequest true "Операции"
+// @Success 200 {object} models.TrxBulkResponse
+// @Failure 422 {object} models.TrxBulkResponse
+// @Router /trx/bulk [post]
+func (tc TrxController) Bulk(c *gin.Context) {
+	var request models.TrxBulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp := tc.service.WithTrx(txHandle).Bulk(request, userID.(uint))
+	if !resp.Applied {
+		// Статус ошибки откатывает транзакцию в DatabaseTrx
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Импорт
+
+// @Security ApiKeyAuth
+// @summary Import trx
+// @tags trx
+// @Description Импорт транзакций из выписки CSV, OFX или QIF в бюджет. Выполняется целиком в одной транзакции БД.
+// @Description Записи OFX, уже импортированные в бюджет (по FITID), пропускаются
+// @ID import_trx
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл выписки"
+// @Param format formData string false "Формат файла: csv, ofx, qif. По умолчанию csv"
+// @Param budget_id formData int true "ID бюджета"
+// @Param date_column formData string false "Колонка даты CSV: название из заголовка или номер с 1"
+// @Param amount_column formData string false "Колонка суммы CSV: название из заголовка или номер с 1"
+// @Param title_column formData string false "Колонка названия: название из заголовка или номер с 1"
+// @Param date_format formData string false "Формат даты CSV и QIF, для CSV по умолчанию DD.MM.YYYY"
+// @Param sign formData string false "Знак суммы: signed, inverted, expense, income. По умолчанию signed"
+// @Param delimiter formData string false "Разделитель колонок, по умолчанию запятая"
+// @Param decimal_separator formData string false "Разделитель дробной части: точка или запятая"
+// @Param header formData bool false "Первая строка - заголовок, по умолчанию true"
+// @Param dry_run formData bool false "Только построить отчёт, не создавая транзакции"
+// @Success 200 {object} models.TrxImportResponse
+// @Router /trx/import [post]
+func (tc TrxController) Import(c *gin.Context) {
+	var request models.TrxImportRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Statement file is required",
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to open file: %s", err.Error()),
+		})
+		return
+	}
+	defer file.Close()
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := tc.service.WithTrx(txHandle).Import(file, request, userID.(uint))
+	if err != nil {
+		if abortWithReferenceError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to import trx: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Выгрузка
+
+// @Security ApiKeyAuth
+// @summary Export trx
+// @tags trx
+// @Description Выгрузка транзакций в CSV, XLSX или NDJSON с теми же фильтрами, что и у списка.
+// @Description Суммы выгружаются точными десятичными значениями
+// @ID export_trx
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Param format query string false "Формат: csv, xlsx, ndjson. По умолчанию csv"
+// @Param amount_min query number false "Минимальная сумма"
+// @Param amount_max query number false "Максимальная сумма"
+// @Param date_from query string false "Дата начала периода в формате 18-10-2004"
+// @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
+// @Param category_id query string false "ID категорий через запятую, null - без категории"
+// @Param tags query string false "Теги: any:a,b - любой из тегов, all:a,b - все теги"
+// @Param status query string false "Статусы через запятую: pending, cleared, reconciled"
+// @Param type query string false "Виды через запятую: income, expense, transfer, split"
+// @Param q query string false "Поиск по названию и заметке"
+// @Success 200 {file} file
+// @Router /trx/export [get]
+func (tc TrxController) Export(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	format := export.Format(c.DefaultQuery("format", string(export.FormatCSV)))
+	contentType, ext, ok := export.ContentType(format)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("unknown export format: %s", format),
+		})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="transactions.%s"`, ext))
+	c.Status(http.StatusOK)
+
+	if err := tc.service.Export(c, userID.(uint), format, c.Writer); err != nil {
+		// Если данные уже начали уходить клиенту, ответ не исправить
+		if c.Writer.Written() {
+			tc.logger.Error("failed to export trx: ", err)
+			return
+		}
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Writer.Header().Del("Content-Disposition")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to export trx: %s", err.Error()),
+		})
+	}
+}
+
+// Дубликаты
+
+// @Security ApiKeyAuth
+// @summary List duplicate trx
+// @tags trx
+// @Description Пары вероятных дубликатов: одинаковые сумма и бюджеты, близкие даты и похожие названия.
+// @Description Отклонённые пары не возвращаются
+// @ID list_duplicates_trx
+// @Accept json
+// @Produce json
+// @Param date_from query string false "Дата начала периода в формате 18-10-2004"
+// @Param date_to query string false "Дата окончания периода в формате 18-10-2004"
+// @Param window query int false "Максимальное расстояние между датами в днях, по умолчанию 3"
+// @Success 200 {array} models.TrxDuplicate
+// @Router /trx/duplicates [get]
+func (tc TrxController) Duplicates(c *gin.Context) {
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	resp, err := tc.service.Duplicates(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to find duplicates: %s", err.Error()),
+		})
+		return
+	}
+
+	if resp == nil {
+		resp = make([]models.TrxDuplicate, 0)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Merge duplicate trx
+// @tags trx
+// @Description Объединение дубликатов: remove_id удаляется, его заметка, категория, получатель,
+// @Description теги и вложения переносятся в keep_id, если там они не заданы
+// @ID merge_duplicates_trx
+// @Accept json
+// @Produce json
+// @Param request body models.TrxDuplicateMergeRequest true "Объединяемые транзакции"
+// @Success 200 {object} models.TrxResponse
+// @Router /trx/duplicates/merge [post]
+func (tc TrxController) MergeDuplicates(c *gin.Context) {
+	var request models.TrxDuplicateMergeRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	trxHandle, _ := c.Get(constants.DBTransaction)
+	txHandle, _ := trxHandle.(*gorm.DB)
+
+	resp, err := tc.service.WithTrx(txHandle).MergeDuplicates(request, userID.(uint))
+	if err != nil {
+		if abortWithReconciledError(c, err) || abortWithForbiddenError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to merge duplicates: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Security ApiKeyAuth
+// @summary Dismiss duplicate trx
+// @tags trx
+// @Description Отметка пары транзакций как не дубликатов
+// @ID dismiss_duplicates_trx
+// @Accept json
+// @Produce json
+// @Param request body models.TrxDuplicateDismissRequest true "Пара транзакций"
+// @Router /trx/duplicates/dismiss [post]
+func (tc TrxController) DismissDuplicate(c *gin.Context) {
+	var request models.TrxDuplicateDismissRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := validators.IsValid(request); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": validators.ParseValidationErrors(err),
+		})
+		return
+	}
+
+	userID, ok := c.Get(constants.UserID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user",
+		})
+		return
+	}
+
+	if err := tc.service.DismissDuplicate(request, userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("failed to dismiss duplicates: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{
+		"message": "duplicates were dismissed",
+	})
+}
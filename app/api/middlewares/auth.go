@@ -64,6 +64,7 @@ func (m JWTAuthMiddleware) Handler() gin.HandlerFunc {
 					})
 				}
 				c.Set(constants.UserID, claims.UserID)
+				lib.RequestInfoFrom(c.Request.Context()).UserID = claims.UserID
 				c.Next()
 				return
 			}
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -44,7 +45,10 @@ func (m DatabaseTrx) Setup() {
 	m.logger.Info("setting up database transaction middleware")
 
 	m.handler.Gin.Use(func(c *gin.Context) {
-		txHandle := m.db.DB.Begin()
+		// Данные запроса доступны репозиториям через контекст транзакции.
+		// Отмена запроса клиентом не прерывает транзакцию
+		ctx := lib.WithRequestInfo(context.Background(), lib.RequestInfoFrom(c.Request.Context()))
+		txHandle := m.db.DB.WithContext(ctx).Begin()
 		m.logger.Info("beginning database transaction")
 
 		defer func() {
@@ -62,6 +66,8 @@ func (m DatabaseTrx) Setup() {
 			m.logger.Info("committing transactions")
 			if err := txHandle.Commit().Error; err != nil {
 				m.logger.Error("trx commit error: ", err)
+			} else {
+				lib.RunAfterCommit(c)
 			}
 		} else {
 			m.logger.Info("rolling back transaction due to status code: 500")
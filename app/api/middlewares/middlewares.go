@@ -5,6 +5,7 @@ import "go.uber.org/fx"
 // Module Middleware exported
 var Module = fx.Options(
 	fx.Provide(NewCorsMiddleware),
+	fx.Provide(NewRequestIDMiddleware),
 	fx.Provide(NewJWTAuthMiddleware),
 	fx.Provide(NewDatabaseTrx),
 	fx.Provide(NewMiddlewares),
@@ -22,11 +23,13 @@ type Middlewares []IMiddleware
 // Register the middleware that should be applied directly (globally)
 func NewMiddlewares(
 	corsMiddleware CorsMiddleware,
+	requestIDMiddleware RequestIDMiddleware,
 	dbTrxMiddleware DatabaseTrx,
 	authMiddleware JWTAuthMiddleware,
 ) Middlewares {
 	return Middlewares{
 		corsMiddleware,
+		requestIDMiddleware,
 		dbTrxMiddleware,
 		authMiddleware,
 	}
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/lib"
+)
+
+// Заголовок с идентификатором запроса
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware middleware for request identification
+type RequestIDMiddleware struct {
+	logger  lib.Logger
+	handler lib.RequestHandler
+}
+
+// NewRequestIDMiddleware creates new request id middleware
+func NewRequestIDMiddleware(logger lib.Logger, handler lib.RequestHandler) RequestIDMiddleware {
+	return RequestIDMiddleware{
+		logger:  logger,
+		handler: handler,
+	}
+}
+
+// Setup sets up request id middleware.
+// Идентификатор берётся из заголовка X-Request-ID или генерируется
+// и возвращается в том же заголовке ответа
+func (m RequestIDMiddleware) Setup() {
+	m.logger.Info("setting up request id middleware")
+
+	m.handler.Gin.Use(func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+
+		c.Request = c.Request.WithContext(lib.WithRequestInfo(c.Request.Context(), &lib.RequestInfo{ID: id}))
+		c.Set(constants.RequestID, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type AttachmentRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.AttachmentController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s AttachmentRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/trx/:id/attachments", s.controller.List)
+		root.POST("/trx/:id/attachments", s.controller.Upload)
+		root.GET("/trx/:id/attachments/:attachment_id", s.controller.Download)
+		root.DELETE("/trx/:id/attachments/:attachment_id", s.controller.Delete)
+	}
+}
+
+func NewAttachmentRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.AttachmentController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) AttachmentRoutes {
+	return AttachmentRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
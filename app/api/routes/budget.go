@@ -16,11 +16,16 @@ type BudgetRoutes struct {
 func (s BudgetRoutes) Setup() {
 	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
 	{
+		root.GET("/budget/tree", s.controller.Tree)
 		root.GET("/budget/:id", s.controller.Get)
 		root.GET("/budget", s.controller.List)
 		root.POST("/budget", s.controller.Post)
 		root.DELETE("/budget/:id", s.controller.Delete)
 		root.PATCH("/budget/:id", s.controller.Patch)
+		root.POST("/budget/:id/reconcile", s.controller.Reconcile)
+		root.POST("/budget/:id/archive", s.controller.Archive)
+		root.POST("/budget/:id/unarchive", s.controller.Unarchive)
+		root.POST("/budget/:id/close", s.controller.Close)
 	}
 }
 
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type BudgetMemberRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.BudgetMemberController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s BudgetMemberRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/budget/invitations", s.controller.Invitations)
+		root.GET("/budget/:id/members", s.controller.List)
+		root.POST("/budget/:id/members", s.controller.Invite)
+		root.POST("/budget/:id/members/accept", s.controller.Accept)
+		root.DELETE("/budget/:id/members/:user_id", s.controller.Remove)
+	}
+}
+
+func NewBudgetMemberRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.BudgetMemberController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) BudgetMemberRoutes {
+	return BudgetMemberRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type CategoryRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.CategoryController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s CategoryRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/trx/category/:id", s.controller.Get)
+		root.GET("/trx/category", s.controller.List)
+		root.POST("/trx/category", s.controller.Store)
+		root.PATCH("/trx/category/:id", s.controller.Patch)
+		root.DELETE("/trx/category/:id", s.controller.Delete)
+	}
+}
+
+func NewCategoryRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.CategoryController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) CategoryRoutes {
+	return CategoryRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
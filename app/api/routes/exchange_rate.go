@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type ExchangeRateRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.ExchangeRateController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s ExchangeRateRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/exchange-rate", s.controller.List)
+		root.POST("/exchange-rate/import", s.controller.Import)
+	}
+}
+
+func NewExchangeRateRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.ExchangeRateController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) ExchangeRateRoutes {
+	return ExchangeRateRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
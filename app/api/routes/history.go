@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+	"finapp/models"
+)
+
+type HistoryRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.HistoryController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s HistoryRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		// Генераторы доступны и по пути остальных их маршрутов
+		prefixes := map[string]models.HistoryEntity{
+			"/trx":           models.HistoryTrx,
+			"/budget":        models.HistoryBudget,
+			"/goal":          models.HistoryGoal,
+			"/generator":     models.HistoryGenerator,
+			"/trx/generator": models.HistoryGenerator,
+		}
+		for prefix, entity := range prefixes {
+			root.GET(prefix+"/:id/history", s.controller.List(entity))
+			root.POST(prefix+"/:id/history/:version/revert", s.controller.Revert(entity))
+		}
+	}
+}
+
+func NewHistoryRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.HistoryController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) HistoryRoutes {
+	return HistoryRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type PayeeRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.PayeeController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s PayeeRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/trx/payee/:id", s.controller.Get)
+		root.GET("/trx/payee", s.controller.List)
+		root.POST("/trx/payee", s.controller.Store)
+		root.PATCH("/trx/payee/:id", s.controller.Patch)
+		root.DELETE("/trx/payee/:id", s.controller.Delete)
+	}
+}
+
+func NewPayeeRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.PayeeController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) PayeeRoutes {
+	return PayeeRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -16,6 +16,15 @@ var Module = fx.Options(
 	fx.Provide(NewBudgetRoutes),
 	fx.Provide(NewTrxRoutes),
 	fx.Provide(NewGeneratorRoutes),
+	fx.Provide(NewCategoryRoutes),
+	fx.Provide(NewTagRoutes),
+	fx.Provide(NewExchangeRateRoutes),
+	fx.Provide(NewAttachmentRoutes),
+	fx.Provide(NewPayeeRoutes),
+	fx.Provide(NewRuleRoutes),
+	fx.Provide(NewTrashRoutes),
+	fx.Provide(NewHistoryRoutes),
+	fx.Provide(NewBudgetMemberRoutes),
 )
 
 // Routes contains multiple routes
@@ -35,6 +44,15 @@ func NewRoutes(
 	budgetRoutes BudgetRoutes,
 	trxRoutes TrxRoutes,
 	generatorRoutes GeneratorRoutes,
+	categoryRoutes CategoryRoutes,
+	tagRoutes TagRoutes,
+	exchangeRateRoutes ExchangeRateRoutes,
+	attachmentRoutes AttachmentRoutes,
+	payeeRoutes PayeeRoutes,
+	ruleRoutes RuleRoutes,
+	trashRoutes TrashRoutes,
+	historyRoutes HistoryRoutes,
+	budgetMemberRoutes BudgetMemberRoutes,
 ) Routes {
 	return Routes{
 		docsRoutes,
@@ -44,6 +62,15 @@ func NewRoutes(
 		budgetRoutes,
 		trxRoutes,
 		generatorRoutes,
+		categoryRoutes,
+		tagRoutes,
+		exchangeRateRoutes,
+		attachmentRoutes,
+		payeeRoutes,
+		ruleRoutes,
+		trashRoutes,
+		historyRoutes,
+		budgetMemberRoutes,
 	}
 }
 
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type RuleRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.RuleController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s RuleRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.POST("/trx/rule/apply", s.controller.Apply)
+		root.GET("/trx/rule/:id", s.controller.Get)
+		root.GET("/trx/rule", s.controller.List)
+		root.POST("/trx/rule", s.controller.Store)
+		root.PUT("/trx/rule/:id", s.controller.Replace)
+		root.DELETE("/trx/rule/:id", s.controller.Delete)
+	}
+}
+
+func NewRuleRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.RuleController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) RuleRoutes {
+	return RuleRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type TagRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.TagController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s TagRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/trx/tag", s.controller.List)
+		root.PATCH("/trx/tag/:id", s.controller.Rename)
+		root.POST("/trx/tag/:id/merge", s.controller.Merge)
+		root.DELETE("/trx/tag/:id", s.controller.Delete)
+	}
+}
+
+func NewTagRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.TagController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) TagRoutes {
+	return TagRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
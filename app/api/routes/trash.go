@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"finapp/api/controllers"
+	"finapp/api/middlewares"
+	"finapp/lib"
+)
+
+type TrashRoutes struct {
+	logger         lib.Logger
+	handler        lib.RequestHandler
+	controller     controllers.TrashController
+	authMiddleware middlewares.JWTAuthMiddleware
+}
+
+func (s TrashRoutes) Setup() {
+	root := s.handler.Gin.Group("/api/v1").Use(s.authMiddleware.Handler())
+	{
+		root.GET("/trash", s.controller.List)
+		root.DELETE("/trash", s.controller.Empty)
+		root.POST("/trash/:entity/:id/restore", s.controller.Restore)
+		root.DELETE("/trash/:entity/:id", s.controller.Purge)
+	}
+}
+
+func NewTrashRoutes(
+	logger lib.Logger,
+	handler lib.RequestHandler,
+	controller controllers.TrashController,
+	authMiddleware middlewares.JWTAuthMiddleware,
+) TrashRoutes {
+	return TrashRoutes{
+		logger:         logger,
+		handler:        handler,
+		controller:     controller,
+		authMiddleware: authMiddleware,
+	}
+}
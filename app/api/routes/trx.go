@@ -18,9 +18,17 @@ func (s TrxRoutes) Setup() {
 	{
 		root.GET("/trx", s.trxController.List)
 		root.POST("/trx", s.trxController.Post)
+		root.POST("/trx/bulk", s.trxController.Bulk)
+		root.POST("/trx/import", s.trxController.Import)
+		root.GET("/trx/export", s.trxController.Export)
+		root.GET("/trx/duplicates", s.trxController.Duplicates)
+		root.POST("/trx/duplicates/merge", s.trxController.MergeDuplicates)
+		root.POST("/trx/duplicates/dismiss", s.trxController.DismissDuplicate)
 		root.GET("/trx/:id", s.trxController.Get)
 		root.PATCH("/trx/:id", s.trxController.Patch)
 		root.DELETE("/trx/:id", s.trxController.Delete)
+		root.PUT("/trx/:id/split", s.trxController.Split)
+		root.POST("/trx/:id/unlock", s.trxController.Unlock)
 	}
 }
 
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+)
+
+// BalanceRebuildCommand пересчитывает снимки остатков бюджетов по транзакциям и генераторам,
+// с --check только сверяет их и завершается ошибкой при расхождении
+type BalanceRebuildCommand struct {
+	budgetID uint
+	check    bool
+}
+
+func (s *BalanceRebuildCommand) Short() string {
+	return "rebuild monthly budget balance snapshots"
+}
+
+func (s *BalanceRebuildCommand) Setup(cmd *cobra.Command) {
+	cmd.Flags().UintVarP(&s.budgetID, "budget", "b", 0, "budget id, all budgets by default")
+	cmd.Flags().BoolVar(&s.check, "check", false, "only compare snapshots with recomputed balances")
+}
+
+func (s *BalanceRebuildCommand) Run() lib.CommandRunner {
+	return func(
+		logger lib.Logger,
+		database lib.Database,
+		service domains.BudgetService,
+	) {
+		if s.check {
+			stale, err := service.CheckBalances(s.budgetID)
+			if err != nil {
+				logger.Fatal("Can't check budget balances: ", err.Error())
+			}
+			if len(stale) > 0 {
+				logger.Fatalf("Balance snapshots of budgets %v are stale, run balance:rebuild", stale)
+			}
+			logger.Info("Balance snapshots are up to date")
+			return
+		}
+
+		var resp models.BudgetBalanceRebuildResponse
+		err := database.Transaction(func(tx *gorm.DB) error {
+			var err error
+			resp, err = service.WithTrx(tx).RebuildBalances(s.budgetID)
+			return err
+		})
+		if err != nil {
+			logger.Fatal("Can't rebuild budget balances: ", err.Error())
+		}
+		logger.Infof("Rebuilt %d balance snapshots of %d budgets", resp.Snapshots, resp.Budgets)
+	}
+}
+
+func NewBalanceRebuildCommand() *BalanceRebuildCommand {
+	return &BalanceRebuildCommand{}
+}
@@ -11,7 +11,9 @@ import (
 )
 
 var cmds = map[string]lib.Command{
-	"app:serve": NewServeCommand(),
+	"app:serve":       NewServeCommand(),
+	"trash:purge":     NewTrashPurgeCommand(),
+	"balance:rebuild": NewBalanceRebuildCommand(),
 }
 
 // GetSubCommands gives a list of sub commands
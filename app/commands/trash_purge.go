@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+)
+
+// TrashPurgeCommand окончательно удаляет записи, пролежавшие в корзине дольше срока хранения
+type TrashPurgeCommand struct {
+	days int
+}
+
+func (s *TrashPurgeCommand) Short() string {
+	return "purge trash older than retention period"
+}
+
+func (s *TrashPurgeCommand) Setup(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&s.days, "days", "d", 0, "retention period in days, TRASH_RETENTION_DAYS by default")
+}
+
+func (s *TrashPurgeCommand) Run() lib.CommandRunner {
+	return func(
+		env lib.Env,
+		logger lib.Logger,
+		database lib.Database,
+		service domains.TrashService,
+	) {
+		days := env.TrashRetentionDays
+		if s.days > 0 {
+			days = s.days
+		}
+
+		before := time.Now().AddDate(0, 0, -days)
+		var (
+			resp models.TrashPurgeResponse
+			keys []string
+		)
+		err := database.Transaction(func(tx *gorm.DB) error {
+			var err error
+			resp, keys, err = service.WithTrx(tx).PurgeExpired(before)
+			return err
+		})
+		if err != nil {
+			logger.Fatal("Can't purge trash: ", err.Error())
+		}
+		// Файлы удаляются только после фиксации транзакции
+		service.DeleteFiles(keys)
+		logger.Infof("Purged %d records deleted before %s", resp.Purged, before.Format(time.DateOnly))
+	}
+}
+
+func NewTrashPurgeCommand() *TrashPurgeCommand {
+	return &TrashPurgeCommand{}
+}
@@ -4,6 +4,12 @@ const (
 	// DBTransaction is database transaction handle set at router context
 	DBTransaction = "db_trx"
 
+	// AfterCommit is list of actions run after database transaction commit
+	AfterCommit = "after_commit"
+
+	// RequestID is request identifier set at router context
+	RequestID = "RequestID"
+
 	// Auth
 	UserID = "UserID"
 )
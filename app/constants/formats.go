@@ -3,3 +3,6 @@ package constants
 const (
 	DateFormat = "02-01-2006"
 )
+
+// Валюта бюджетов и транзакций по умолчанию
+const DefaultCurrency = "RUB"
@@ -0,0 +1,19 @@
+package domains
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type AttachmentService interface {
+	WithTrx(trxHandle *gorm.DB) AttachmentService
+	List(c *gin.Context, userID uint) ([]models.AttachmentResponse, error)
+	Upload(c *gin.Context, userID uint) (models.AttachmentResponse, error)
+	// Возвращает вложение и его содержимое, вызывающий закрывает reader
+	Download(c *gin.Context, userID uint) (models.AttachmentResponse, io.ReadCloser, error)
+	Delete(c *gin.Context, userID uint) error
+}
@@ -9,9 +9,16 @@ import (
 
 type BudgetService interface {
 	WithTrx(trxHandle *gorm.DB) BudgetService
-	List(c *gin.Context, userID uint) ([]models.BudgetGetResponse, error)
+	List(c *gin.Context, userID uint) (models.PageResponse[models.BudgetGetResponse], error)
 	Get(c *gin.Context, userID uint) (models.BudgetGetResponse, error)
+	Tree(c *gin.Context, userID uint) ([]models.BudgetTreeResponse, error)
 	Create(request *models.BudgetCreateRequest, userID uint) (models.BudgetCreateResponse, error)
 	Patch(c *gin.Context, budget models.BudgetPatchRequest, userID uint) (models.BudgetPatchResponse, error)
 	Delete(c *gin.Context, userID uint) error
+	Archive(c *gin.Context, userID uint) (models.BudgetArchiveResponse, error)
+	Unarchive(c *gin.Context, userID uint) (models.BudgetArchiveResponse, error)
+	Close(c *gin.Context, request models.BudgetCloseRequest, userID uint) (models.BudgetCloseResponse, error)
+	Reconcile(c *gin.Context, request models.BudgetReconcileRequest, userID uint) (models.BudgetReconcileResponse, error)
+	RebuildBalances(budgetID uint) (models.BudgetBalanceRebuildResponse, error)
+	CheckBalances(budgetID uint) ([]uint, error)
 }
@@ -0,0 +1,17 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type BudgetMemberService interface {
+	WithTrx(trxHandle *gorm.DB) BudgetMemberService
+	List(c *gin.Context, userID uint) ([]models.BudgetMemberResponse, error)
+	Invitations(userID uint) ([]models.BudgetMemberResponse, error)
+	Invite(c *gin.Context, request models.BudgetInviteRequest, userID uint) (models.BudgetMemberResponse, error)
+	Accept(c *gin.Context, userID uint) (models.BudgetMemberResponse, error)
+	Remove(c *gin.Context, userID uint) error
+}
@@ -0,0 +1,17 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type CategoryService interface {
+	WithTrx(trxHandle *gorm.DB) CategoryService
+	List(userID uint) ([]models.CategoryResponse, error)
+	Get(c *gin.Context, userID uint) (models.CategoryResponse, error)
+	Store(request *models.CategoryStoreRequest, userID uint) (models.CategoryResponse, error)
+	Patch(c *gin.Context, request models.CategoryPatchRequest, userID uint) (models.CategoryResponse, error)
+	Delete(c *gin.Context, userID uint) error
+}
@@ -0,0 +1,14 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type ExchangeRateService interface {
+	WithTrx(trxHandle *gorm.DB) ExchangeRateService
+	List(c *gin.Context, userID uint) ([]models.ExchangeRateResponse, error)
+	Import(request models.ExchangeRateImportRequest, userID uint) (models.ExchangeRateImportResponse, error)
+}
@@ -4,11 +4,13 @@ import (
 	"finapp/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type GeneratorService interface {
+	WithTrx(trxHandle *gorm.DB) GeneratorService
 	Store(generator models.GeneratorStoreRequest, userID uint) (models.GeneratorResponse, error)
-	List(userID uint) ([]models.GeneratorResponse, error)
+	List(c *gin.Context, userID uint) (models.PageResponse[models.GeneratorResponse], error)
 	Get(c *gin.Context, userID uint) (models.GeneratorResponse, error)
 	Update(c *gin.Context, generator models.GeneratorPatchRequest, userID uint) (models.GeneratorResponse, error)
 	Delete(c *gin.Context, userID uint) error
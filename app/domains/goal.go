@@ -9,7 +9,7 @@ import (
 
 type GoalService interface {
 	WithTrx(trxHandle *gorm.DB) GoalService
-	List(c *gin.Context, userID uint) ([]models.GoalCalcResponse, error)
+	List(c *gin.Context, userID uint) (models.PageResponse[models.GoalCalcResponse], error)
 	Get(c *gin.Context, userID uint) (models.GoalCalcResponse, error)
 	Store(request *models.GoalStoreRequest, userID uint) (models.GoalResponse, error)
 	Update(c *gin.Context, req models.GoalUpdateRequest, userID uint) (models.GoalResponse, error)
@@ -0,0 +1,14 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type HistoryService interface {
+	WithTrx(trxHandle *gorm.DB) HistoryService
+	List(c *gin.Context, entity models.HistoryEntity, userID uint) ([]models.HistoryResponse, error)
+	Revert(c *gin.Context, entity models.HistoryEntity, userID uint) (models.HistoryResponse, error)
+}
@@ -0,0 +1,17 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type PayeeService interface {
+	WithTrx(trxHandle *gorm.DB) PayeeService
+	List(userID uint) ([]models.PayeeResponse, error)
+	Get(c *gin.Context, userID uint) (models.PayeeResponse, error)
+	Store(request *models.PayeeStoreRequest, userID uint) (models.PayeeResponse, error)
+	Patch(c *gin.Context, request models.PayeePatchRequest, userID uint) (models.PayeeResponse, error)
+	Delete(c *gin.Context, userID uint) error
+}
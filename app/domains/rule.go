@@ -0,0 +1,18 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type RuleService interface {
+	WithTrx(trxHandle *gorm.DB) RuleService
+	List(userID uint) ([]models.RuleResponse, error)
+	Get(c *gin.Context, userID uint) (models.RuleResponse, error)
+	Store(request *models.RuleRequest, userID uint) (models.RuleResponse, error)
+	Replace(c *gin.Context, request *models.RuleRequest, userID uint) (models.RuleResponse, error)
+	Delete(c *gin.Context, userID uint) error
+	Apply(c *gin.Context, request models.RuleApplyRequest, userID uint) (models.RuleApplyResponse, error)
+}
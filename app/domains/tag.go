@@ -0,0 +1,14 @@
+package domains
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"finapp/models"
+)
+
+type TagService interface {
+	List(userID uint) ([]models.TagResponse, error)
+	Rename(c *gin.Context, request models.TagRenameRequest, userID uint) (models.TagResponse, error)
+	Merge(c *gin.Context, request models.TagMergeRequest, userID uint) (models.TagResponse, error)
+	Delete(c *gin.Context, userID uint) error
+}
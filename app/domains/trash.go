@@ -0,0 +1,20 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+type TrashService interface {
+	WithTrx(trxHandle *gorm.DB) TrashService
+	List(c *gin.Context, userID uint) ([]models.TrashItemResponse, error)
+	Restore(c *gin.Context, userID uint) error
+	Purge(c *gin.Context, userID uint) error
+	Empty(c *gin.Context, userID uint) (models.TrashPurgeResponse, error)
+	PurgeExpired(before time.Time) (models.TrashPurgeResponse, []string, error)
+	DeleteFiles(keys []string)
+}
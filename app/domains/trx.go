@@ -1,17 +1,28 @@
 package domains
 
 import (
+	"io"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"finapp/lib/export"
 	"finapp/models"
 )
 
 type TrxService interface {
 	WithTrx(trxHandle *gorm.DB) TrxService
-	List(c *gin.Context, userID uint) ([]models.TrxResponse, error)
+	List(c *gin.Context, userID uint) (models.PageResponse[models.TrxResponse], error)
 	Get(c *gin.Context, userID uint) (models.TrxResponse, error)
 	Create(trxRequest *models.TrxRequest, userID uint) (models.TrxResponse, error)
 	Patch(c *gin.Context, transaction models.TrxPatchRequest, userID uint) (models.TrxResponse, error)
 	Delete(c *gin.Context, userID uint) error
+	Split(c *gin.Context, request models.TrxSplitRequest, userID uint) (models.TrxResponse, error)
+	Unlock(c *gin.Context, userID uint) (models.TrxResponse, error)
+	Bulk(request models.TrxBulkRequest, userID uint) models.TrxBulkResponse
+	Export(c *gin.Context, userID uint, format export.Format, w io.Writer) error
+	Import(file io.Reader, request models.TrxImportRequest, userID uint) (models.TrxImportResponse, error)
+	Duplicates(c *gin.Context, userID uint) ([]models.TrxDuplicate, error)
+	MergeDuplicates(request models.TrxDuplicateMergeRequest, userID uint) (models.TrxResponse, error)
+	DismissDuplicate(request models.TrxDuplicateDismissRequest, userID uint) error
 }
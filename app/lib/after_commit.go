@@ -0,0 +1,22 @@
+package lib
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+)
+
+// AfterCommit откладывает действие до фиксации транзакции запроса
+// (например, удаление файла из хранилища). При откате действие не выполняется
+func AfterCommit(c *gin.Context, fn func()) {
+	fns, _ := c.Value(constants.AfterCommit).([]func())
+	c.Set(constants.AfterCommit, append(fns, fn))
+}
+
+// RunAfterCommit выполняет действия, отложенные до фиксации транзакции запроса
+func RunAfterCommit(c *gin.Context) {
+	fns, _ := c.Value(constants.AfterCommit).([]func())
+	for _, fn := range fns {
+		fn()
+	}
+}
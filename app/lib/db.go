@@ -42,11 +42,44 @@ func NewDatabase(env Env, logger Logger) Database {
 	}
 	logger.Info("Connected to database")
 
-	if err := db.AutoMigrate(&models.User{}, models.Trx{}, models.Budget{}, models.Goal{}, &models.Generator{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, models.Trx{}, models.Budget{}, models.Goal{}, &models.Generator{}, &models.Category{}, &models.Tag{}, &models.ImportedEntry{}, &models.ExchangeRate{}, &models.Attachment{}, &models.Payee{}, &models.Rule{}, &models.DuplicateDismissal{}, &models.History{}, &models.BudgetMember{}, &models.BudgetBalance{}); err != nil {
 		logger.Panic("Can't migrate database: ", err.Error())
 	}
 	logger.Info("Migrated database")
 
+	// Вид транзакций, созданных до его появления: сначала по своим бюджетам,
+	// затем разделённым - по видам частей, split если они различаются
+	if err := db.Exec("UPDATE transactions SET type = CASE "+
+		"WHEN budget_from IS NOT NULL AND budget_to IS NOT NULL THEN ? WHEN budget_to IS NOT NULL THEN ? ELSE ? END "+
+		"WHERE (type IS NULL OR type = '') AND is_split = ?",
+		models.TrxTypeTransfer, models.TrxTypeIncome, models.TrxTypeExpense, false).Error; err != nil {
+		logger.Panic("Can't fill trx types: ", err.Error())
+	}
+	legTypes := "FROM transactions legs WHERE legs.parent_id = transactions.id AND legs.deleted_at IS NULL"
+	if err := db.Exec("UPDATE transactions SET type = CASE "+
+		"WHEN (SELECT COUNT(DISTINCT legs.type) "+legTypes+") > 1 THEN ? "+
+		"ELSE COALESCE((SELECT MIN(legs.type) "+legTypes+"), ?) END "+
+		"WHERE (type IS NULL OR type = '') AND is_split = ?",
+		models.TrxTypeSplit, models.TrxTypeExpense, true).Error; err != nil {
+		logger.Panic("Can't fill trx types: ", err.Error())
+	}
+
+	// Создатели бюджетов, созданных до появления общих бюджетов, - их владельцы
+	if err := db.Exec("INSERT INTO budget_members (budget_id, user_id, role, invited_by, accepted_at, created_at, updated_at) "+
+		"SELECT id, user_id, ?, user_id, created_at, created_at, created_at FROM budgets WHERE NOT EXISTS "+
+		"(SELECT 1 FROM budget_members WHERE budget_members.budget_id = budgets.id AND budget_members.user_id = budgets.user_id)",
+		models.BudgetRoleOwner).Error; err != nil {
+		logger.Panic("Can't fill budget owners: ", err.Error())
+	}
+
+	// Индекс полнотекстового поиска по транзакциям
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_search ON transactions " +
+			"USING GIN (to_tsvector('russian', coalesce(title, '') || ' ' || coalesce(note, '')))").Error; err != nil {
+			logger.Panic("Can't create search index: ", err.Error())
+		}
+	}
+
 	return Database{
 		db,
 	}
@@ -26,6 +26,16 @@ type Env struct {
 	// Logs
 	LogOutput string `mapstructure:"LOG_OUTPUT"`
 	LogLevel  string `mapstructure:"LOG_LEVEL"`
+	// Attachments
+	StorageBackend string `mapstructure:"STORAGE_BACKEND"`
+	StoragePath    string `mapstructure:"STORAGE_PATH"`
+	// Максимальный размер вложения в байтах
+	AttachmentMaxSize int64 `mapstructure:"ATTACHMENT_MAX_SIZE"`
+	// Разрешённые MIME типы вложений через пробел
+	AttachmentMIMETypes string `mapstructure:"ATTACHMENT_MIME_TYPES"`
+	// Trash
+	// Сколько дней удалённые записи хранятся в корзине
+	TrashRetentionDays int `mapstructure:"TRASH_RETENTION_DAYS"`
 }
 
 func NewEnv() Env {
@@ -45,10 +55,17 @@ func NewEnv() Env {
 	// CORS
 	viper.SetDefault("ALLOWED_ORIGINS", "*")
 	viper.SetDefault("ALLOWED_METHODS", "GET HEAD POST PUT DELETE OPTIONS PATCH")
-	viper.SetDefault("ALLOWED_HEADERS", "Content-Type Authorization Accept Cache-Control Allow")
+	viper.SetDefault("ALLOWED_HEADERS", "Content-Type Authorization Accept Cache-Control Allow X-Request-ID")
 	// Logs
 	viper.SetDefault("LOG_OUTPUT", "logs")
 	viper.SetDefault("LOG_LEVEL", "debug")
+	// Attachments
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("STORAGE_PATH", "attachments")
+	viper.SetDefault("ATTACHMENT_MAX_SIZE", 10<<20)
+	viper.SetDefault("ATTACHMENT_MIME_TYPES", "image/jpeg image/png image/webp application/pdf")
+	// Trash
+	viper.SetDefault("TRASH_RETENTION_DAYS", 30)
 
 	viper.AutomaticEnv()
 
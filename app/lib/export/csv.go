@@ -0,0 +1,36 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (Writer, error) {
+	writer := csv.NewWriter(w)
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.Name)
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return csvWriter{writer: writer}, nil
+}
+
+func (w csvWriter) WriteRow(values []string) error {
+	if err := w.writer.Write(values); err != nil {
+		return err
+	}
+	// Сбрасываем буфер построчно, чтобы клиент получал данные сразу
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,54 @@
+// Package export потоково записывает табличные данные в CSV, XLSX и NDJSON
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatXLSX   Format = "xlsx"
+	FormatNDJSON Format = "ndjson"
+)
+
+type Column struct {
+	Name string
+	// Числовая колонка: в XLSX пишется числом, в NDJSON - числом без кавычек
+	Numeric bool
+}
+
+// Writer пишет строки по одной, не накапливая их в памяти.
+// Пустое значение числовой колонки означает отсутствие значения
+type Writer interface {
+	WriteRow(values []string) error
+	// Дописывает окончание файла и сбрасывает буферы
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	}
+	return nil, fmt.Errorf("unknown export format: %s", format)
+}
+
+// MIME тип и расширение файла формата
+func ContentType(format Format) (string, string, bool) {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8", "csv", true
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", true
+	case FormatNDJSON:
+		return "application/x-ndjson", "ndjson", true
+	}
+	return "", "", false
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type ndjsonWriter struct {
+	writer  *bufio.Writer
+	columns []Column
+}
+
+func newNDJSONWriter(w io.Writer, columns []Column) Writer {
+	return ndjsonWriter{
+		writer:  bufio.NewWriter(w),
+		columns: columns,
+	}
+}
+
+func (w ndjsonWriter) WriteRow(values []string) error {
+	if err := w.writer.WriteByte('{'); err != nil {
+		return err
+	}
+	for i, column := range w.columns {
+		if i > 0 {
+			_ = w.writer.WriteByte(',')
+		}
+		name, _ := json.Marshal(column.Name)
+		_, _ = w.writer.Write(name)
+		_ = w.writer.WriteByte(':')
+
+		var value []byte
+		switch {
+		case i >= len(values) || (column.Numeric && values[i] == ""):
+			value = []byte("null")
+		case column.Numeric && json.Valid([]byte(values[i])):
+			// Число пишется как есть, без перевода во float
+			value = []byte(values[i])
+		default:
+			value, _ = json.Marshal(values[i])
+		}
+		if _, err := w.writer.Write(value); err != nil {
+			return err
+		}
+	}
+	if _, err := w.writer.WriteString("}\n"); err != nil {
+		return err
+	}
+	return w.writer.Flush()
+}
+
+func (w ndjsonWriter) Close() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,28 @@
+package lib
+
+import "context"
+
+// RequestInfo данные запроса, доступные репозиториям через контекст транзакции.
+// UserID заполняется после авторизации
+type RequestInfo struct {
+	ID     string
+	UserID uint
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo возвращает контекст с данными запроса
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom возвращает данные запроса из контекста,
+// вне запроса (например, в командах) - пустые
+func RequestInfoFrom(ctx context.Context) *RequestInfo {
+	if ctx != nil {
+		if info, ok := ctx.Value(requestInfoKey{}).(*RequestInfo); ok {
+			return info
+		}
+	}
+	return &RequestInfo{}
+}
@@ -0,0 +1,159 @@
+package statements
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Сопоставление колонок CSV файла полям транзакции.
+// Колонка задаётся названием из заголовка или номером, начиная с 1
+type CSVMapping struct {
+	DateColumn       string
+	AmountColumn     string
+	TitleColumn      string
+	DateFormat       string
+	Delimiter        string
+	DecimalSeparator string
+	HasHeader        bool
+}
+
+func ParseCSV(r io.Reader, mapping CSVMapping) ([]Entry, error) {
+	reader := csv.NewReader(skipBOM(r))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	switch mapping.Delimiter {
+	case "":
+	case `\t`, "tab":
+		reader.Comma = '\t'
+	default:
+		delimiter, size := utf8.DecodeRuneInString(mapping.Delimiter)
+		if size != len(mapping.Delimiter) {
+			return nil, errors.New("delimiter must be a single character")
+		}
+		reader.Comma = delimiter
+	}
+
+	var header []string
+	if mapping.HasHeader {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("csv file is empty")
+			}
+			return nil, err
+		}
+		header = record
+	}
+
+	dateIdx, err := columnIndex(header, mapping.DateColumn)
+	if err != nil {
+		return nil, fmt.Errorf("date column: %w", err)
+	}
+	amountIdx, err := columnIndex(header, mapping.AmountColumn)
+	if err != nil {
+		return nil, fmt.Errorf("amount column: %w", err)
+	}
+	titleIdx := -1
+	if mapping.TitleColumn != "" {
+		titleIdx, err = columnIndex(header, mapping.TitleColumn)
+		if err != nil {
+			return nil, fmt.Errorf("title column: %w", err)
+		}
+	}
+
+	dateFormat := ConvertDateFormat(mapping.DateFormat)
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Пустые строки csv.Reader пропускает сам, поэтому номер берётся из позиции поля
+		line, _ := reader.FieldPos(0)
+		entry := Entry{Line: line}
+
+		if isEmptyRecord(record) {
+			entry.Empty = true
+			entries = append(entries, entry)
+			continue
+		}
+
+		if dateIdx >= len(record) || amountIdx >= len(record) || titleIdx >= len(record) {
+			entry.Err = errors.New("not enough columns")
+			entries = append(entries, entry)
+			continue
+		}
+
+		date, err := time.Parse(dateFormat, strings.TrimSpace(record[dateIdx]))
+		if err != nil {
+			entry.Err = fmt.Errorf("invalid date: %w", err)
+			entries = append(entries, entry)
+			continue
+		}
+
+		amount, err := ParseAmount(record[amountIdx], mapping.DecimalSeparator)
+		if err != nil {
+			entry.Err = fmt.Errorf("invalid amount: %w", err)
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Date = date
+		entry.Amount = amount
+		if titleIdx >= 0 {
+			entry.Title = strings.TrimSpace(record[titleIdx])
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Ищет колонку по названию из заголовка или по номеру
+func columnIndex(header []string, column string) (int, error) {
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return 0, errors.New("column is not set")
+	}
+
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+
+	num, err := strconv.Atoi(column)
+	if err != nil || num < 1 {
+		return 0, fmt.Errorf("column %q not found", column)
+	}
+	return num - 1, nil
+}
+
+// Выгрузки из Excel часто начинаются с BOM, который ломает название первой колонки
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		_, _ = br.Discard(3)
+	}
+	return br
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
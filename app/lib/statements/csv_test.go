@@ -0,0 +1,206 @@
+package statements
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Ожидаемая запись: дата в формате 2006-01-02, пустая дата - запись с ошибкой
+type wantEntry struct {
+	line   int
+	date   string
+	amount string
+	title  string
+	empty  bool
+}
+
+func assertEntries(t *testing.T, entries []Entry, want []wantEntry) {
+	t.Helper()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Line != w.line {
+			t.Errorf("entry %d: line = %d, want %d", i, e.Line, w.line)
+		}
+		if w.empty {
+			if !e.Empty {
+				t.Errorf("entry %d: expected empty entry, got %+v", i, e)
+			}
+			continue
+		}
+		if w.date == "" {
+			if e.Err == nil {
+				t.Errorf("entry %d: expected error, got %+v", i, e)
+			}
+			continue
+		}
+		if e.Err != nil {
+			t.Errorf("entry %d: unexpected error %v", i, e.Err)
+			continue
+		}
+		if date := e.Date.Format("2006-01-02"); date != w.date {
+			t.Errorf("entry %d: date = %s, want %s", i, date, w.date)
+		}
+		if !e.Amount.Equal(decimal.RequireFromString(w.amount)) {
+			t.Errorf("entry %d: amount = %s, want %s", i, e.Amount, w.amount)
+		}
+		if e.Title != w.title {
+			t.Errorf("entry %d: title = %q, want %q", i, e.Title, w.title)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		mapping CSVMapping
+		want    []wantEntry
+		wantErr string
+	}{
+		{
+			name:    "header columns by name",
+			content: "Дата;Сумма;Описание\n15.03.2024;-1 234,56;Магазин\n16.03.2024;+500,00;Зарплата\n",
+			mapping: CSVMapping{DateColumn: "дата", AmountColumn: "СУММА", TitleColumn: "Описание", DateFormat: "DD.MM.YYYY", Delimiter: ";", DecimalSeparator: ",", HasHeader: true},
+			want: []wantEntry{
+				{line: 2, date: "2024-03-15", amount: "-1234.56", title: "Магазин"},
+				{line: 3, date: "2024-03-16", amount: "500", title: "Зарплата"},
+			},
+		},
+		{
+			name:    "columns by number without header",
+			content: "Shop,2024-01-02,\"1,234.50\"\n",
+			mapping: CSVMapping{DateColumn: "2", AmountColumn: "3", TitleColumn: "1", DateFormat: "YYYY-MM-DD"},
+			want:    []wantEntry{{line: 1, date: "2024-01-02", amount: "1234.50", title: "Shop"}},
+		},
+		{
+			name:    "header with BOM",
+			content: "\xef\xbb\xbfdate,amount\n01/02/24,10\n",
+			mapping: CSVMapping{DateColumn: "date", AmountColumn: "amount", DateFormat: "01/02/06", HasHeader: true},
+			want:    []wantEntry{{line: 2, date: "2024-01-02", amount: "10"}},
+		},
+		{
+			name:    "tab delimiter and short year",
+			content: "02.01.24\t-7.5\tCafe\n",
+			mapping: CSVMapping{DateColumn: "1", AmountColumn: "2", TitleColumn: "3", DateFormat: "DD.MM.YY", Delimiter: `\t`},
+			want:    []wantEntry{{line: 1, date: "2024-01-02", amount: "-7.5", title: "Cafe"}},
+		},
+		{
+			name:    "empty and broken rows keep line numbers",
+			content: "date,amount\n2024-01-01,1\n,\n2024-13-01,2\n2024-01-03,abc\n2024-01-04\n\n2024-01-05,3\n",
+			mapping: CSVMapping{DateColumn: "date", AmountColumn: "amount", DateFormat: "2006-01-02", HasHeader: true},
+			want: []wantEntry{
+				{line: 2, date: "2024-01-01", amount: "1"},
+				{line: 3, empty: true},
+				{line: 4},
+				{line: 5},
+				{line: 6},
+				{line: 8, date: "2024-01-05", amount: "3"},
+			},
+		},
+		{
+			name:    "unknown header column",
+			content: "date,amount\n",
+			mapping: CSVMapping{DateColumn: "date", AmountColumn: "sum", HasHeader: true},
+			wantErr: "amount column",
+		},
+		{
+			name:    "empty file with header",
+			content: "",
+			mapping: CSVMapping{DateColumn: "date", AmountColumn: "amount", HasHeader: true},
+			wantErr: "empty",
+		},
+		{
+			name:    "multi-character delimiter",
+			content: "a,b\n",
+			mapping: CSVMapping{DateColumn: "1", AmountColumn: "2", Delimiter: ";;"},
+			wantErr: "delimiter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ParseCSV(strings.NewReader(tt.content), tt.mapping)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertEntries(t, entries, tt.want)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value     string
+		separator string
+		want      string
+		wantErr   bool
+	}{
+		{"1234.56", ".", "1234.56", false},
+		{"1,234.56", ".", "1234.56", false},
+		{"1 234,56", ",", "1234.56", false},
+		{"1.234,56", ",", "1234.56", false},
+		{"1 234,5", ",", "1234.5", false},
+		{"1'234.50", "", "1234.5", false},
+		{"+10", ".", "10", false},
+		{"-0,01", ",", "-0.01", false},
+		{"", ".", "", true},
+		{"  ", ",", "", true},
+		{"abc", ".", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseAmount(tt.value, tt.separator)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertDateFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		want   string
+	}{
+		{"DD.MM.YYYY", "05.03.2024", "2024-03-05"},
+		{"YYYY-MM-DD", "2024-03-05", "2024-03-05"},
+		{"MM/DD/YY", "03/05/24", "2024-03-05"},
+		{"DD.MM.YYYY hh:mm:ss", "05.03.2024 23:10:00", "2024-03-05"},
+		{"02.01.2006", "05.03.2024", "2024-03-05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			date, err := time.Parse(ConvertDateFormat(tt.format), tt.value)
+			if err != nil {
+				t.Fatalf("parse %q with %q: %v", tt.value, ConvertDateFormat(tt.format), err)
+			}
+			if got := date.Format("2006-01-02"); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
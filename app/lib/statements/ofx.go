@@ -0,0 +1,93 @@
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ofxTagRe = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+
+// Разбирает OFX выписку. Поддерживаются SGML (OFX 1.x) и XML (OFX 2.x) варианты.
+// Номером записи считается порядковый номер STMTTRN в файле
+func ParseOFX(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	if !strings.Contains(strings.ToUpper(content), "<OFX>") {
+		return nil, errors.New("file is not an OFX statement")
+	}
+
+	blocks := splitOFXTransactions(content)
+	entries := make([]Entry, 0, len(blocks))
+	for i, block := range blocks {
+		entry := Entry{Line: i + 1}
+		fields := make(map[string]string)
+		for _, m := range ofxTagRe.FindAllStringSubmatch(block, -1) {
+			fields[strings.ToUpper(m[1])] = html.UnescapeString(strings.TrimSpace(m[2]))
+		}
+
+		entry.ExternalID = fields["FITID"]
+
+		date, err := parseOFXDate(fields["DTPOSTED"])
+		if err != nil {
+			entry.Err = fmt.Errorf("invalid date: %w", err)
+			entries = append(entries, entry)
+			continue
+		}
+
+		amount, err := ParseAmount(fields["TRNAMT"], guessDecimalSeparator(fields["TRNAMT"]))
+		if err != nil {
+			entry.Err = fmt.Errorf("invalid amount: %w", err)
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Date = date
+		entry.Amount = amount
+		entry.Title = fields["NAME"]
+		if entry.Title == "" {
+			entry.Title = fields["MEMO"]
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Выделяет содержимое блоков STMTTRN. В SGML варианте закрывающие теги необязательны,
+// поэтому блок заканчивается на следующем STMTTRN или конце списка
+func splitOFXTransactions(content string) []string {
+	upper := strings.ToUpper(content)
+	var blocks []string
+	for {
+		start := strings.Index(upper, "<STMTTRN>")
+		if start < 0 {
+			return blocks
+		}
+		content, upper = content[start+len("<STMTTRN>"):], upper[start+len("<STMTTRN>"):]
+
+		end := len(content)
+		for _, closing := range []string{"</STMTTRN>", "<STMTTRN>", "</BANKTRANLIST>"} {
+			if idx := strings.Index(upper, closing); idx >= 0 && idx < end {
+				end = idx
+			}
+		}
+		blocks = append(blocks, content[:end])
+		content, upper = content[end:], upper[end:]
+	}
+}
+
+// Дата OFX: YYYYMMDD[HHMMSS[.XXX]][[+-]TZ[:name]], важна только календарная дата
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("date %q is too short", value)
+	}
+	return time.Parse("20060102", value[:8])
+}
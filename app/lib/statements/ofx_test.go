@@ -0,0 +1,117 @@
+package statements
+
+import (
+	"strings"
+	"testing"
+)
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<DTSTART>20240101
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240115120000.000[+3:MSK]
+<TRNAMT>-1234.56
+<FITID>A1
+<NAME>Coffee &amp; Co
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240116
+<TRNAMT>500,10
+<FITID>A2
+<MEMO>Salary
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+    <STMTTRN>
+      <TRNTYPE>DEBIT</TRNTYPE>
+      <DTPOSTED>20240201</DTPOSTED>
+      <TRNAMT>-1.234,50</TRNAMT>
+      <FITID>X1</FITID>
+      <NAME>Rent</NAME>
+      <MEMO>February</MEMO>
+    </STMTTRN>
+    <stmttrn>
+      <dtposted>2024</dtposted>
+      <trnamt>1</trnamt>
+      <fitid>X2</fitid>
+    </stmttrn>
+    <STMTTRN>
+      <DTPOSTED>20240203</DTPOSTED>
+      <TRNAMT></TRNAMT>
+      <FITID>X3</FITID>
+    </STMTTRN>
+  </BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		want        []wantEntry
+		externalIDs []string
+		wantErr     bool
+	}{
+		{
+			name:    "sgml without closing tags",
+			content: ofxSGML,
+			want: []wantEntry{
+				{line: 1, date: "2024-01-15", amount: "-1234.56", title: "Coffee & Co"},
+				{line: 2, date: "2024-01-16", amount: "500.10", title: "Salary"},
+			},
+			externalIDs: []string{"A1", "A2"},
+		},
+		{
+			name:    "xml with closing tags",
+			content: ofxXML,
+			want: []wantEntry{
+				{line: 1, date: "2024-02-01", amount: "-1234.50", title: "Rent"},
+				{line: 2},
+				{line: 3},
+			},
+			externalIDs: []string{"X1", "X2", "X3"},
+		},
+		{
+			name:    "no transactions",
+			content: "<OFX><BANKTRANLIST></BANKTRANLIST></OFX>",
+		},
+		{
+			name:    "not an ofx file",
+			content: "date,amount\n2024-01-01,1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ParseOFX(strings.NewReader(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertEntries(t, entries, tt.want)
+			for i, id := range tt.externalIDs {
+				if entries[i].ExternalID != id {
+					t.Errorf("entry %d: external id = %q, want %q", i, entries[i].ExternalID, id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package statements
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Форматы дат, которые встречаются в QIF выгрузках
+var qifDateFormats = []string{
+	"01/02/2006",
+	"1/2/2006",
+	"01/02/06",
+	"1/2/06",
+	"02.01.2006",
+	"2006-01-02",
+}
+
+// Разбирает QIF выписку. Если формат даты не задан, перебираются распространённые форматы.
+// Номером записи считается номер строки, на которой запись начинается
+func ParseQIF(r io.Reader, dateFormat string) ([]Entry, error) {
+	formats := qifDateFormats
+	if dateFormat != "" {
+		formats = []string{ConvertDateFormat(dateFormat)}
+	}
+
+	scanner := bufio.NewScanner(skipBOM(r))
+	var (
+		entries []Entry
+		fields  = make(map[byte]string)
+		start   int
+		line    int
+		isQIF   bool
+	)
+
+	flush := func() {
+		if len(fields) == 0 {
+			return
+		}
+		entries = append(entries, newQIFEntry(start, fields, formats))
+		fields = make(map[byte]string)
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if text[0] == '!' {
+			isQIF = true
+			continue
+		}
+		if text[0] == '^' {
+			flush()
+			continue
+		}
+		if len(fields) == 0 {
+			start = line
+		}
+		// Для разбиения (S, E, $) берётся только первая строка, нужна сумма всей записи
+		if _, ok := fields[text[0]]; !ok {
+			fields[text[0]] = strings.TrimSpace(text[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if !isQIF {
+		return nil, errors.New("file is not a QIF statement")
+	}
+	return entries, nil
+}
+
+func newQIFEntry(line int, fields map[byte]string, formats []string) Entry {
+	entry := Entry{Line: line}
+
+	// Год вида 1/2'24 в Quicken означает 2024
+	dateStr := strings.ReplaceAll(fields['D'], "'", "/")
+	var err error
+	for _, format := range formats {
+		entry.Date, err = time.Parse(format, dateStr)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Entry{Line: line, Err: fmt.Errorf("invalid date: %w", err)}
+	}
+
+	amountStr := fields['T']
+	if amountStr == "" {
+		amountStr = fields['U']
+	}
+	amount, err := ParseAmount(amountStr, guessDecimalSeparator(amountStr))
+	if err != nil {
+		return Entry{Line: line, Err: fmt.Errorf("invalid amount: %w", err)}
+	}
+	entry.Amount = amount
+
+	entry.Title = fields['P']
+	if entry.Title == "" {
+		entry.Title = fields['M']
+	}
+	return entry
+}
@@ -0,0 +1,73 @@
+package statements
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQIF(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		dateFormat string
+		want       []wantEntry
+		wantErr    bool
+	}{
+		{
+			name: "records end with caret",
+			content: "!Type:Bank\n" +
+				"D01/15/2024\nT-1,234.56\nPCoffee\nMmorning\n^\n" +
+				"D1/16'24\nU500.00\nMSalary\n^\n",
+			want: []wantEntry{
+				{line: 2, date: "2024-01-15", amount: "-1234.56", title: "Coffee"},
+				{line: 7, date: "2024-01-16", amount: "500", title: "Salary"},
+			},
+		},
+		{
+			name:    "last record without caret and crlf",
+			content: "!Type:Bank\r\nD2024-03-01\r\nT10\r\nPShop\r\n^\r\n\r\nD2024-03-02\r\nT-5\r\n",
+			want: []wantEntry{
+				{line: 2, date: "2024-03-01", amount: "10", title: "Shop"},
+				{line: 7, date: "2024-03-02", amount: "-5"},
+			},
+		},
+		{
+			name: "split record takes total amount",
+			content: "!Type:Bank\n" +
+				"D05.03.2024\nT-100,50\nPMarket\nSFood\n$-60,50\nSHome\n$-40\n^\n",
+			want: []wantEntry{{line: 2, date: "2024-03-05", amount: "-100.50", title: "Market"}},
+		},
+		{
+			name:       "explicit date format",
+			content:    "!Type:Bank\nD03/05/2024\nT1\n^\n",
+			dateFormat: "DD/MM/YYYY",
+			want:       []wantEntry{{line: 2, date: "2024-05-03", amount: "1"}},
+		},
+		{
+			name:    "broken records",
+			content: "!Type:Bank\nD31/31/2024\nT1\n^\nD01/01/2024\nTabc\n^\nD01/02/2024\n^\n",
+			want:    []wantEntry{{line: 2}, {line: 5}, {line: 8}},
+		},
+		{
+			name:    "not a qif file",
+			content: "D01/01/2024\nT1\n^\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ParseQIF(strings.NewReader(tt.content), tt.dateFormat)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertEntries(t, entries, tt.want)
+		})
+	}
+}
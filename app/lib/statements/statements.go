@@ -0,0 +1,79 @@
+// Package statements разбирает банковские выписки в записи для импорта транзакций
+package statements
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Запись выписки
+type Entry struct {
+	// Номер строки (записи) в файле, начиная с 1
+	Line   int
+	Date   time.Time
+	Amount decimal.Decimal
+	Title  string
+	// Идентификатор записи в банке (FITID в OFX), если есть
+	ExternalID string
+	// Ошибка разбора записи, остальные поля в этом случае не заполнены
+	Err error
+	// Пустая запись, которую нужно пропустить
+	Empty bool
+}
+
+var ErrEmptyAmount = errors.New("amount is empty")
+
+// Переводит формат даты вида DD.MM.YYYY в формат Go.
+// Форматы, уже записанные в виде Go (02.01.2006), возвращаются как есть
+func ConvertDateFormat(format string) string {
+	if strings.Contains(format, "2006") {
+		return format
+	}
+	return strings.NewReplacer(
+		"YYYY", "2006",
+		"YY", "06",
+		"MM", "01",
+		"DD", "02",
+		"hh", "15",
+		"mm", "04",
+		"ss", "05",
+	).Replace(format)
+}
+
+// Разбирает сумму с учётом разделителя дробной части.
+// Пробелы и разделители разрядов отбрасываются
+func ParseAmount(value string, decimalSeparator string) (decimal.Decimal, error) {
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'':
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return decimal.Decimal{}, ErrEmptyAmount
+	}
+
+	if decimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	// Знак плюса decimal не поддерживает
+	value = strings.TrimPrefix(value, "+")
+
+	return decimal.NewFromString(value)
+}
+
+// Разделитель дробной части суммы без явного формата: последний из точки и запятой,
+// разделители разрядов идут раньше него
+func guessDecimalSeparator(value string) string {
+	if strings.LastIndex(value, ",") > strings.LastIndex(value, ".") {
+		return ","
+	}
+	return "."
+}
@@ -0,0 +1,22 @@
+// Package storage хранит файлы вложений. Бэкенд выбирается настройкой
+// STORAGE_BACKEND: local - файловая система, memory - память процесса (для тестов)
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("file not found")
+	ErrInvalidKey = errors.New("invalid file key")
+)
+
+type Storage interface {
+	// Сохраняет содержимое r под ключом key, перезаписывая существующий файл
+	Save(key string, r io.Reader) error
+	// Открывает файл на чтение, вызывающий закрывает его
+	Open(key string) (io.ReadCloser, error)
+	// Удаляет файл, отсутствие файла не считается ошибкой
+	Delete(key string) error
+}
@@ -0,0 +1,20 @@
+package lib
+
+import (
+	"fmt"
+
+	"finapp/lib/storage"
+)
+
+// Хранилище файлов вложений по настройке STORAGE_BACKEND
+func NewStorage(env Env, logger Logger) (storage.Storage, error) {
+	switch env.StorageBackend {
+	case "memory":
+		logger.Info("Using in-memory attachments storage")
+		return storage.NewMemory(), nil
+	case "local", "":
+		logger.Info("Using local attachments storage: ", env.StoragePath)
+		return storage.NewLocal(env.StoragePath)
+	}
+	return nil, fmt.Errorf("unknown storage backend: %s", env.StorageBackend)
+}
@@ -4,18 +4,55 @@ import (
 	"finapp/constants"
 	"finapp/models"
 	"log"
+	"reflect"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
 )
 
 func customValidator() *validator.Validate {
 	v := validator.New()
 	v.RegisterValidation("isNotFutureDate", isNotFutureDate)
 	v.RegisterValidation("periodicity", periodicityValidation)
+	v.RegisterValidation("money", moneyValidation)
+	v.RegisterValidation("positive", positiveValidation)
+	v.RegisterValidation("nonzero", nonZeroValidation)
+	v.RegisterCustomTypeFunc(optionalDecimalValue, models.Optional[decimal.Decimal]{})
 	return v
 }
 
+// Суммы проверяются как decimal, без перевода в float64: встроенные
+// required, numeric и gt для них не подходят
+func decimalOf(fldLvl validator.FieldLevel) (decimal.Decimal, bool) {
+	value, ok := fldLvl.Field().Interface().(decimal.Decimal)
+	return value, ok
+}
+
+// Денежная сумма - не больше двух знаков после запятой
+func moneyValidation(fldLvl validator.FieldLevel) bool {
+	value, ok := decimalOf(fldLvl)
+	return ok && value.Equal(value.Truncate(2))
+}
+
+func positiveValidation(fldLvl validator.FieldLevel) bool {
+	value, ok := decimalOf(fldLvl)
+	return ok && value.IsPositive()
+}
+
+func nonZeroValidation(fldLvl validator.FieldLevel) bool {
+	value, ok := decimalOf(fldLvl)
+	return ok && !value.IsZero()
+}
+
+// Сумма в PATCH запросе проверяется, только если передана
+func optionalDecimalValue(field reflect.Value) any {
+	if value, ok := field.Interface().(models.Optional[decimal.Decimal]); ok && value.Value != nil {
+		return *value.Value
+	}
+	return nil
+}
+
 func isNotFutureDate(fldLvl validator.FieldLevel) bool {
 	dateToValidateStr := fldLvl.Field().String()
 	dateToValidate, err := time.Parse(constants.DateFormat, dateToValidateStr)
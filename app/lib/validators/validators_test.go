@@ -0,0 +1,55 @@
+package validators
+
+import (
+	"encoding/json"
+	"testing"
+
+	"finapp/models"
+)
+
+func TestAmountValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		request any
+		body    string
+		field   string
+		tag     string
+	}{
+		{"trx amount", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"1500.50"}`, "", ""},
+		{"trx negative amount", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"-10"}`, "", ""},
+		{"trx amount as number", &models.TrxRequest{}, `{"date":"2024-01-01","amount":12.3}`, "", ""},
+		{"trx zero amount", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"0"}`, "Amount", "nonzero"},
+		{"trx fraction of kopeck", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"0.001"}`, "Amount", "money"},
+		{"trx trailing zeros", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"1.500"}`, "", ""},
+		{"trx negative dest amount", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"1","dest_amount":"-1"}`, "DestAmount", "positive"},
+		{"trx dest amount precision", &models.TrxRequest{}, `{"date":"2024-01-01","amount":"1","dest_amount":"0.015"}`, "DestAmount", "money"},
+		{"leg precision", &models.TrxSplitRequest{}, `{"legs":[{"amount":"1"},{"amount":"2.345"}]}`, "Amount", "money"},
+		{"patch without amount", &models.TrxPatchRequest{}, `{"title":"x"}`, "", ""},
+		{"patch null amount", &models.TrxPatchRequest{}, `{"amount":null}`, "", ""},
+		{"patch zero amount", &models.TrxPatchRequest{}, `{"amount":"0"}`, "", ""},
+		{"patch amount precision", &models.TrxPatchRequest{}, `{"amount":"0.001"}`, "Amount", "money"},
+		{"generator amount", &models.GeneratorStoreRequest{}, `{"amount":"100.01","periodicity":"monthly"}`, "", ""},
+		{"generator amount precision", &models.GeneratorStoreRequest{}, `{"amount":"100.011","periodicity":"monthly"}`, "Amount", "money"},
+		{"goal zero target", &models.GoalStoreRequest{}, `{"title":"x","target_amount":"0"}`, "TargetAmount", "nonzero"},
+		{"rate precision", &models.ExchangeRateRequest{}, `{"date":"2024-01-01","base":"USD","quote":"RUB","rate":"91.12345678"}`, "", ""},
+		{"zero rate", &models.ExchangeRateRequest{}, `{"date":"2024-01-01","base":"USD","quote":"RUB","rate":"0"}`, "Rate", "positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.body), tt.request); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			errs := ParseValidationErrors(IsValid(tt.request))
+			if tt.field == "" {
+				if len(errs) != 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if errs[tt.field] != tt.tag {
+				t.Fatalf("errors = %v, want %s: %s", errs, tt.field, tt.tag)
+			}
+		})
+	}
+}
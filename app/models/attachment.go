@@ -0,0 +1,29 @@
+package models
+
+import "gorm.io/gorm"
+
+// Файл, приложенный к транзакции. Содержимое лежит в хранилище под StorageKey
+type Attachment struct {
+	gorm.Model
+	UserID      uint
+	User        User `gorm:"foreignKey:UserID"`
+	TrxID       uint `gorm:"index"`
+	Trx         Trx  `gorm:"foreignKey:TrxID"`
+	FileName    string
+	ContentType string
+	Size        int64
+	StorageKey  string
+}
+
+func (a Attachment) TableName() string {
+	return "attachments"
+}
+
+type AttachmentResponse struct {
+	ID          uint   `json:"id"`
+	TrxID       uint   `json:"trx_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	CreatedAt   string `json:"created_at"`
+}
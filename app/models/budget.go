@@ -7,33 +7,148 @@ import (
 	"time"
 )
 
+// Лимит расходов бюджета: не больше Amount за каждый период Period,
+// периоды отсчитываются от StartDate
+type BudgetLimit struct {
+	Amount    decimal.Decimal `json:"amount" validate:"positive,money" swaggertype:"string"`
+	Period    Periodicity     `json:"period" validate:"periodicity"`
+	StartDate string          `json:"start_date" validate:"required"`
+}
+
 type BudgetCreateRequest struct {
 	Title string `json:"title" validate:"required"`
 	Goal  *uint  `json:"goal_id"`
+	// Код валюты ISO 4217, по умолчанию RUB
+	Currency string       `json:"currency" validate:"omitempty,iso4217"`
+	Limit    *BudgetLimit `json:"limit"`
+	// Родительский бюджет, например карта внутри группы "Карты"
+	Parent *uint `json:"parent_id"`
 }
 
 type BudgetCreateResponse struct {
-	ID     uint   `json:"id"`
-	Title  string `json:"title"`
-	GoadID *uint  `json:"goad_id"`
+	ID       uint         `json:"id"`
+	Title    string       `json:"title"`
+	GoadID   *uint        `json:"goad_id"`
+	Currency string       `json:"currency"`
+	Limit    *BudgetLimit `json:"limit"`
+	Parent   *uint        `json:"parent_id"`
 }
 
 type BudgetPatchRequest struct {
 	Title string `json:"title"`
 	Goal  *uint  `json:"goal_id"`
+	// null снимает лимит
+	Limit Optional[BudgetLimit] `json:"limit" swaggertype:"object"`
+	// null переносит бюджет на верхний уровень
+	Parent Optional[uint] `json:"parent_id" swaggertype:"integer"`
 }
 
 type BudgetPatchResponse struct {
-	ID    uint   `json:"id"`
+	ID     uint         `json:"id"`
+	Title  string       `json:"title"`
+	Goal   *uint        `json:"goal_id"`
+	Limit  *BudgetLimit `json:"limit"`
+	Parent *uint        `json:"parent_id"`
+}
+
+// Расходы бюджета за период лимита. Переводы между бюджетами в расходы не входят
+type BudgetLimitPeriod struct {
+	DateFrom string `json:"date_from"`
+	// Последний день периода
+	DateTo    string          `json:"date_to"`
+	Spent     decimal.Decimal `json:"spent" swaggertype:"string"`
+	Remaining decimal.Decimal `json:"remaining" swaggertype:"string"`
+	Over      bool            `json:"over"`
+}
+
+type BudgetLimitResponse struct {
+	BudgetLimit
+	// Текущий период, nil до начала первого периода
+	Current *BudgetLimitPeriod `json:"current"`
+	// Прошедшие периоды, сначала последние
+	Past []BudgetLimitPeriod `json:"past"`
+	// Расходы текущего периода при сохранении темпа к его концу
+	Projected decimal.Decimal `json:"projected" swaggertype:"string"`
+	// Лимит текущего периода превышен
+	OverLimit bool `json:"over_limit"`
+	// Лимит ещё не превышен, но будет при сохранении темпа расходов
+	OnPaceToExceed bool `json:"on_pace_to_exceed"`
+}
+
+type BudgetGetResponse struct {
 	Title string `json:"title"`
+	ID    uint   `json:"id"`
 	Goal  *uint  `json:"goal_id"`
+	// Родительский бюджет
+	Parent *uint `json:"parent_id"`
+	// Валюта Amounts: запрошенная или валюта бюджета
+	Currency string `json:"currency"`
+	// С include_children=true суммы включают все вложенные бюджеты
+	Amounts map[string]decimal.Decimal `json:"amounts" swaggertype:"object,string"`
+	// Доходы и расходы за период без переводов между бюджетами
+	Income  decimal.Decimal `json:"income" swaggertype:"string"`
+	Expense decimal.Decimal `json:"expense" swaggertype:"string"`
+	// Только для бюджетов с лимитом, суммы в валюте бюджета
+	Limit *BudgetLimitResponse `json:"limit,omitempty"`
+	// Дата архивации, null у действующего бюджета
+	ArchivedAt *string `json:"archived_at"`
+	// Роль пользователя в бюджете
+	Role BudgetRole `json:"role"`
 }
 
-type BudgetGetResponse struct {
-	Title   string             `json:"title"`
-	ID      uint               `json:"id"`
-	Goal    *uint              `json:"goal_id"`
-	Amounts map[string]float64 `json:"amounts"`
+type BudgetArchiveResponse struct {
+	ID         uint    `json:"id"`
+	ArchivedAt *string `json:"archived_at"`
+}
+
+// Закрытие бюджета: остаток на дату Date переводится в бюджет BudgetTo
+type BudgetCloseRequest struct {
+	BudgetTo uint `json:"budget_to" validate:"required"`
+	// По умолчанию сегодня
+	Date string `json:"date"`
+}
+
+type BudgetCloseResponse struct {
+	ID         uint   `json:"id"`
+	ArchivedAt string `json:"archived_at"`
+	// Остаток бюджета, переведённый в BudgetTo
+	Balance decimal.Decimal `json:"balance" swaggertype:"string"`
+	// Транзакция перевода остатка, null при нулевом остатке
+	TrxID *uint `json:"trx_id"`
+	// Число остановленных генераторов
+	StoppedGenerators int64 `json:"stopped_generators"`
+}
+
+// Узел дерева бюджетов
+type BudgetTreeResponse struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Parent *uint  `json:"parent_id"`
+	// Валюта сумм: запрошенная или валюта бюджета
+	Currency string `json:"currency"`
+	// Остаток самого бюджета на дату
+	Amount decimal.Decimal `json:"amount" swaggertype:"string"`
+	// Остаток вместе со всеми вложенными бюджетами
+	Total    decimal.Decimal      `json:"total" swaggertype:"string"`
+	Children []BudgetTreeResponse `json:"children"`
+}
+
+// Сверка бюджета с выпиской: остаток на дату выписки
+type BudgetReconcileRequest struct {
+	Date    string          `json:"date" validate:"required"`
+	Balance decimal.Decimal `json:"balance" validate:"numeric" swaggertype:"string"`
+}
+
+type BudgetReconcileResponse struct {
+	// false, если остаток не сошёлся, транзакции тогда не блокируются
+	Reconciled       bool            `json:"reconciled"`
+	Date             string          `json:"date"`
+	StatementBalance decimal.Decimal `json:"statement_balance" swaggertype:"string"`
+	// Остаток бюджета на дату без транзакций в статусе pending
+	ClearedBalance decimal.Decimal `json:"cleared_balance" swaggertype:"string"`
+	Difference     decimal.Decimal `json:"difference" swaggertype:"string"`
+	// Число транзакций, отмеченных сверенными
+	ReconciledCount int64 `json:"reconciled_count"`
 }
 
 type Budget struct {
@@ -43,6 +158,16 @@ type Budget struct {
 	Title  string
 	GoalID *sql.NullInt64
 	Goal   Goal `gorm:"foreignKey:GoalID"`
+	// Родительский бюджет того же пользователя, nil - бюджет верхнего уровня
+	ParentID *sql.NullInt64 `gorm:"index"`
+	// Код валюты ISO 4217
+	Currency string `gorm:"size:3;not null;default:RUB"`
+	// Лимит расходов за период, nil - без лимита
+	LimitAmount *decimal.Decimal
+	LimitPeriod Periodicity `gorm:"size:16"`
+	LimitStart  *sql.NullTime
+	// Архивный бюджет скрыт из списка, но учитывается в отчётах
+	ArchivedAt *sql.NullTime `gorm:"index"`
 }
 
 func (b Budget) TableName() string {
@@ -51,5 +176,8 @@ func (b Budget) TableName() string {
 
 type BudgetChanges struct {
 	AmountChange decimal.Decimal
-	Date         time.Time
+	// Доходы и расходы за дату, без переводов между бюджетами
+	Income  decimal.Decimal
+	Expense decimal.Decimal
+	Date    time.Time
 }
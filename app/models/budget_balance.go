@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Снимок остатка бюджета на конец месяца. Снимки бюджета идут подряд
+// с первого месяца движения по нему, последний снимок - граница,
+// до которой остатки посчитаны. Остаток включает транзакции всех
+// участников бюджета и срабатывания генераторов
+type BudgetBalance struct {
+	ID        uint `gorm:"primarykey"`
+	UpdatedAt time.Time
+	BudgetID  uint `gorm:"uniqueIndex:idx_budget_balance"`
+	// Первое число месяца
+	Month   time.Time `gorm:"uniqueIndex:idx_budget_balance"`
+	Balance decimal.Decimal
+}
+
+func (b BudgetBalance) TableName() string {
+	return "budget_balances"
+}
+
+type BudgetBalanceRebuildResponse struct {
+	// Число бюджетов с пересчитанными снимками
+	Budgets int `json:"budgets"`
+	// Число записанных снимков
+	Snapshots int `json:"snapshots"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Роль участника общего бюджета
+type BudgetRole string
+
+const (
+	// Управляет бюджетом и участниками
+	BudgetRoleOwner BudgetRole = "owner"
+	// Изменяет бюджет и его транзакции
+	BudgetRoleEditor BudgetRole = "editor"
+	// Только просматривает
+	BudgetRoleViewer BudgetRole = "viewer"
+)
+
+// Роли с правом изменять бюджет и его транзакции
+var BudgetWriteRoles = []BudgetRole{BudgetRoleOwner, BudgetRoleEditor}
+
+type BudgetInviteRequest struct {
+	// Почта приглашаемого пользователя
+	Email string     `json:"email" validate:"required,email"`
+	Role  BudgetRole `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type BudgetMemberResponse struct {
+	BudgetID  uint       `json:"budget_id"`
+	UserID    uint       `json:"user_id"`
+	Email     *string    `json:"email"`
+	Role      BudgetRole `json:"role"`
+	InvitedBy uint       `json:"invited_by"`
+	// false, пока приглашение не принято
+	Accepted bool `json:"accepted"`
+}
+
+// Участие пользователя в бюджете. Создатель бюджета - его первый владелец,
+// остальные участники получают доступ, приняв приглашение
+type BudgetMember struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	BudgetID  uint       `gorm:"uniqueIndex:idx_budget_member"`
+	Budget    Budget     `gorm:"foreignKey:BudgetID"`
+	UserID    uint       `gorm:"uniqueIndex:idx_budget_member;index"`
+	User      User       `gorm:"foreignKey:UserID"`
+	Role      BudgetRole `gorm:"size:16;not null"`
+	InvitedBy uint
+	// Пусто, пока приглашение не принято
+	AcceptedAt *sql.NullTime
+}
+
+func (m BudgetMember) TableName() string {
+	return "budget_members"
+}
@@ -0,0 +1,51 @@
+package models
+
+import "encoding/json"
+
+// Тип операции пакетного изменения транзакций
+type BulkOp string
+
+const (
+	BulkOpCreate BulkOp = "create"
+	BulkOpPatch  BulkOp = "patch"
+	BulkOpDelete BulkOp = "delete"
+)
+
+type BulkOpStatus string
+
+const (
+	BulkOpApplied BulkOpStatus = "applied"
+	BulkOpFailed  BulkOpStatus = "failed"
+	// Операция не выполнялась из-за ошибки в предыдущей
+	BulkOpSkipped BulkOpStatus = "skipped"
+)
+
+type TrxBulkOperation struct {
+	Op BulkOp `json:"op" validate:"required,oneof=create patch delete"`
+	// ID транзакции для patch и delete
+	ID uint `json:"id" validate:"required_unless=Op create"`
+	// TrxRequest для create, TrxPatchRequest для patch
+	Data json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type TrxBulkRequest struct {
+	Operations []TrxBulkOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+type TrxBulkResult struct {
+	// Индекс операции в запросе
+	Index  int          `json:"index"`
+	Op     BulkOp       `json:"op"`
+	Status BulkOpStatus `json:"status"`
+	// Транзакция после create и patch
+	Trx   *TrxResponse `json:"trx,omitempty"`
+	Error any          `json:"error,omitempty"`
+}
+
+type TrxBulkResponse struct {
+	// Все операции применены
+	Applied bool `json:"applied"`
+	// Индекс первой неудачной операции, null если все применены
+	FailedIndex *int            `json:"failed_index"`
+	Results     []TrxBulkResult `json:"results"`
+}
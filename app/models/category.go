@@ -0,0 +1,27 @@
+package models
+
+import "gorm.io/gorm"
+
+type CategoryStoreRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type CategoryPatchRequest struct {
+	Title string `json:"title"`
+}
+
+type CategoryResponse struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+type Category struct {
+	gorm.Model
+	UserID uint
+	User   User `gorm:"foreignKey:UserID"`
+	Title  string
+}
+
+func (c Category) TableName() string {
+	return "categories"
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Курс валюты на дату: 1 Base = Rate Quote
+type ExchangeRate struct {
+	gorm.Model
+	UserID uint            `gorm:"uniqueIndex:idx_exchange_rate"`
+	User   User            `gorm:"foreignKey:UserID"`
+	Date   time.Time       `gorm:"uniqueIndex:idx_exchange_rate"`
+	Base   string          `gorm:"size:3;uniqueIndex:idx_exchange_rate"`
+	Quote  string          `gorm:"size:3;uniqueIndex:idx_exchange_rate"`
+	Rate   decimal.Decimal `sql:"type:decimal(20,8);"`
+}
+
+func (r ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+type ExchangeRateRequest struct {
+	Date  string          `json:"date" validate:"required"`
+	Base  string          `json:"base" validate:"required,iso4217"`
+	Quote string          `json:"quote" validate:"required,iso4217,nefield=Base"`
+	Rate  decimal.Decimal `json:"rate" validate:"positive" swaggertype:"string"`
+}
+
+// Курсы на одну дату и пару валют перезаписываются
+type ExchangeRateImportRequest struct {
+	Rates []ExchangeRateRequest `json:"rates" validate:"required,min=1,dive"`
+}
+
+type ExchangeRateImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+type ExchangeRateResponse struct {
+	Date  string          `json:"date"`
+	Base  string          `json:"base"`
+	Quote string          `json:"quote"`
+	Rate  decimal.Decimal `json:"rate" swaggertype:"string"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "gorm.io/gorm"
+
+// Пара транзакций, отмеченная пользователем как не дубликат.
+// TrxID всегда меньше OtherID
+type DuplicateDismissal struct {
+	gorm.Model
+	UserID  uint `gorm:"uniqueIndex:idx_duplicate_dismissal"`
+	TrxID   uint `gorm:"uniqueIndex:idx_duplicate_dismissal"`
+	OtherID uint `gorm:"uniqueIndex:idx_duplicate_dismissal"`
+}
+
+func (d DuplicateDismissal) TableName() string {
+	return "duplicate_dismissals"
+}
+
+// Пара похожих транзакций: одинаковые сумма и бюджеты,
+// близкие даты и названия. Trx - более ранняя из них
+type TrxDuplicate struct {
+	Trx       TrxResponse `json:"trx"`
+	Duplicate TrxResponse `json:"duplicate"`
+	// Сходство названий от 0 до 1
+	Similarity float64 `json:"similarity"`
+	DaysApart  int     `json:"days_apart"`
+}
+
+// Транзакция RemoveID удаляется, её заметка, категория, получатель,
+// теги и вложения переносятся в KeepID, если там они не заданы
+type TrxDuplicateMergeRequest struct {
+	KeepID   uint `json:"keep_id" validate:"required"`
+	RemoveID uint `json:"remove_id" validate:"required,nefield=KeepID"`
+}
+
+type TrxDuplicateDismissRequest struct {
+	TrxID       uint `json:"trx_id" validate:"required"`
+	DuplicateID uint `json:"duplicate_id" validate:"required,nefield=TrxID"`
+}
@@ -17,37 +17,37 @@ const (
 )
 
 type GeneratorStoreRequest struct {
-	Title             string      `json:"title"`
-	Amount            float64     `json:"amount" validate:"numeric"`
-	Periodicity       Periodicity `json:"periodicity" validate:"periodicity"`
-	PeriodicityFactor uint        `json:"periodicity_factor"`
-	BudgetFrom        *uint       `json:"budget_from"`
-	BudgetTo          *uint       `json:"budget_to"`
-	DateFrom          string      `json:"date_from"`
-	DateTo            *string     `json:"date_to"`
+	Title             string          `json:"title"`
+	Amount            decimal.Decimal `json:"amount" validate:"money" swaggertype:"string"`
+	Periodicity       Periodicity     `json:"periodicity" validate:"periodicity"`
+	PeriodicityFactor uint            `json:"periodicity_factor"`
+	BudgetFrom        *uint           `json:"budget_from"`
+	BudgetTo          *uint           `json:"budget_to"`
+	DateFrom          string          `json:"date_from"`
+	DateTo            *string         `json:"date_to"`
 }
 
 type GeneratorPatchRequest struct {
-	Title             string      `json:"title"`
-	Amount            float64     `json:"amount" validate:"numeric"`
-	Periodicity       Periodicity `json:"periodicity" validate:"periodicity"`
-	PeriodicityFactor uint        `json:"periodicity_factor"`
-	BudgetFrom        *uint       `json:"budget_from"`
-	BudgetTo          *uint       `json:"budget_to"`
-	DateTo            string      `json:"date_to"`
-	DateFrom          string      `json:"date_from"`
+	Title             string          `json:"title"`
+	Amount            decimal.Decimal `json:"amount" validate:"money" swaggertype:"string"`
+	Periodicity       Periodicity     `json:"periodicity" validate:"periodicity"`
+	PeriodicityFactor uint            `json:"periodicity_factor"`
+	BudgetFrom        *uint           `json:"budget_from"`
+	BudgetTo          *uint           `json:"budget_to"`
+	DateTo            string          `json:"date_to"`
+	DateFrom          string          `json:"date_from"`
 }
 
 type GeneratorResponse struct {
-	ID                uint        `json:"id"`
-	Title             string      `json:"title"`
-	Amount            float64     `json:"amount"`
-	Periodicity       Periodicity `json:"periodicity"`
-	PeriodicityFactor uint        `json:"periodicity_factor"`
-	BudgetFrom        *uint       `json:"budget_from"`
-	BudgetTo          *uint       `json:"budget_to"`
-	DateFrom          string      `json:"date_from"`
-	DateTo            *string     `json:"date_to"`
+	ID                uint            `json:"id"`
+	Title             string          `json:"title"`
+	Amount            decimal.Decimal `json:"amount" swaggertype:"string"`
+	Periodicity       Periodicity     `json:"periodicity"`
+	PeriodicityFactor uint            `json:"periodicity_factor"`
+	BudgetFrom        *uint           `json:"budget_from"`
+	BudgetTo          *uint           `json:"budget_to"`
+	DateFrom          string          `json:"date_from"`
+	DateTo            *string         `json:"date_to"`
 }
 
 type Generator struct {
@@ -23,26 +23,31 @@ func (m Goal) TableName() string {
 // Requests/Responses
 // / Store
 type GoalStoreRequest struct {
-	Title        string  `json:"title" validate:"required"`
-	TargetAmount float64 `json:"target_amount" validate:"required,numeric"`
+	Title        string          `json:"title" validate:"required"`
+	TargetAmount decimal.Decimal `json:"target_amount" validate:"nonzero,money" swaggertype:"string"`
 }
 
 type GoalCalcResponse struct {
-	ID           uint               `json:"id"`
-	Title        string             `json:"title"`
-	Amounts      map[string]float64 `json:"amount"`
-	TargetAmount float64            `json:"target_amount"`
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	// Валюта Amounts, в неё пересчитываются бюджеты в других валютах
+	Currency     string                     `json:"currency"`
+	Amounts      map[string]decimal.Decimal `json:"amount" swaggertype:"object,string"`
+	TargetAmount decimal.Decimal            `json:"target_amount" swaggertype:"string"`
+	// Доходы и расходы бюджетов цели за период без переводов между бюджетами
+	Income  decimal.Decimal `json:"income" swaggertype:"string"`
+	Expense decimal.Decimal `json:"expense" swaggertype:"string"`
 }
 
 // / Get
 type GoalResponse struct {
-	ID           uint    `json:"id"`
-	Title        string  `json:"title"`
-	TargetAmount float64 `json:"target_amount"`
+	ID           uint            `json:"id"`
+	Title        string          `json:"title"`
+	TargetAmount decimal.Decimal `json:"target_amount" swaggertype:"string"`
 }
 
 // Update
 type GoalUpdateRequest struct {
-	Title        string  `json:"title"`
-	TargetAmount float64 `json:"target_amount"`
+	Title        string          `json:"title"`
+	TargetAmount decimal.Decimal `json:"target_amount" validate:"money" swaggertype:"string"`
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Сущности, для которых ведётся история изменений
+type HistoryEntity string
+
+const (
+	HistoryTrx       HistoryEntity = "trx"
+	HistoryBudget    HistoryEntity = "budget"
+	HistoryGoal      HistoryEntity = "goal"
+	HistoryGenerator HistoryEntity = "generator"
+)
+
+type HistoryAction string
+
+const (
+	HistoryCreate HistoryAction = "create"
+	HistoryUpdate HistoryAction = "update"
+	HistoryDelete HistoryAction = "delete"
+	// Восстановление из корзины
+	HistoryRestore HistoryAction = "restore"
+)
+
+// Версия записи: значения её колонок до и после изменения в JSON.
+// Все изменения записи в одном запросе объединяются в одну версию
+type History struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	// Владелец записи
+	UserID   uint          `gorm:"index"`
+	Entity   HistoryEntity `gorm:"size:16;uniqueIndex:idx_history_version"`
+	RecordID uint          `gorm:"uniqueIndex:idx_history_version"`
+	Version  uint          `gorm:"uniqueIndex:idx_history_version"`
+	Action   HistoryAction `gorm:"size:16"`
+	// Пусто при создании записи
+	Before *string `gorm:"type:text"`
+	// Пусто при удалении записи
+	After *string `gorm:"type:text"`
+	// Пользователь, сделавший изменение
+	ChangedBy uint
+	RequestID string `gorm:"size:64;index"`
+}
+
+func (h History) TableName() string {
+	return "history"
+}
+
+type HistoryResponse struct {
+	Version   uint            `json:"version"`
+	Action    HistoryAction   `json:"action"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	ChangedBy uint            `json:"changed_by"`
+	RequestID string          `json:"request_id"`
+	// Время изменения в RFC 3339
+	ChangedAt string `json:"changed_at"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import "gorm.io/gorm"
+
+// Формат файла выписки
+type ImportFormat string
+
+const (
+	ImportFormatCSV ImportFormat = "csv"
+	ImportFormatOFX ImportFormat = "ofx"
+	ImportFormatQIF ImportFormat = "qif"
+)
+
+// Правило знака суммы в выписке
+type SignConvention string
+
+const (
+	// Отрицательная сумма - расход, положительная - доход
+	SignSigned SignConvention = "signed"
+	// Положительная сумма - расход, отрицательная - доход
+	SignInverted SignConvention = "inverted"
+	// Все записи - расходы
+	SignExpense SignConvention = "expense"
+	// Все записи - доходы
+	SignIncome SignConvention = "income"
+)
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated ImportRowStatus = "created"
+	ImportRowSkipped ImportRowStatus = "skipped"
+	ImportRowInvalid ImportRowStatus = "invalid"
+)
+
+// Параметры импорта выписки, передаются полями multipart формы вместе с файлом
+type TrxImportRequest struct {
+	Format   ImportFormat   `form:"format,default=csv" validate:"oneof=csv ofx qif"`
+	BudgetID uint           `form:"budget_id" validate:"required"`
+	Sign     SignConvention `form:"sign,default=signed" validate:"oneof=signed inverted expense income"`
+	DryRun   bool           `form:"dry_run"`
+	// Колонки CSV: название из заголовка или номер, начиная с 1
+	DateColumn   string `form:"date_column" validate:"required_if=Format csv"`
+	AmountColumn string `form:"amount_column" validate:"required_if=Format csv"`
+	TitleColumn  string `form:"title_column"`
+	// Для CSV по умолчанию DD.MM.YYYY, для QIF перебираются распространённые форматы
+	DateFormat string `form:"date_format"`
+	// По умолчанию запятая, для табуляции - tab
+	Delimiter        string `form:"delimiter"`
+	DecimalSeparator string `form:"decimal_separator,default=." validate:"oneof=. ,"`
+	HasHeader        bool   `form:"header,default=true"`
+}
+
+type TrxImportRowReport struct {
+	// Номер строки в файле
+	Row    int             `json:"row"`
+	Status ImportRowStatus `json:"status"`
+	// Транзакция, созданная (или которая была бы создана) из строки
+	Trx   *TrxResponse `json:"trx,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+type TrxImportResponse struct {
+	DryRun  bool                 `json:"dry_run"`
+	Created int                  `json:"created"`
+	Skipped int                  `json:"skipped"`
+	Invalid int                  `json:"invalid"`
+	Rows    []TrxImportRowReport `json:"rows"`
+}
+
+// Запись выписки, уже импортированная в бюджет.
+// Хранится отдельно от транзакции, чтобы удаление транзакции не приводило к повторному импорту
+type ImportedEntry struct {
+	gorm.Model
+	UserID     uint   `gorm:"uniqueIndex:idx_imported_entry"`
+	BudgetID   uint   `gorm:"uniqueIndex:idx_imported_entry"`
+	ExternalID string `gorm:"uniqueIndex:idx_imported_entry"`
+	TrxID      uint
+}
+
+func (e ImportedEntry) TableName() string {
+	return "imported_entries"
+}
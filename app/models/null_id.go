@@ -0,0 +1,21 @@
+package models
+
+import "database/sql"
+
+// Ссылка на запись для колонки, допускающей NULL: nil - ссылки нет.
+// Возвращает не nil, чтобы gorm записал NULL при обновлении
+func NullID(id *uint) *sql.NullInt64 {
+	if id == nil {
+		return &sql.NullInt64{}
+	}
+	return &sql.NullInt64{Int64: int64(*id), Valid: true}
+}
+
+// ID из колонки ссылки, nil для NULL
+func IDFromNull(id *sql.NullInt64) *uint {
+	if id == nil || !id.Valid {
+		return nil
+	}
+	value := uint(id.Int64)
+	return &value
+}
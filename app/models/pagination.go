@@ -0,0 +1,28 @@
+package models
+
+// Параметры постраничного вывода (keyset пагинация)
+type PageRequest struct {
+	Limit int
+	// Название поля сортировки из запроса
+	Sort string
+	// Выражение, по которому идёт сортировка, и его параметры
+	SortColumn string
+	SortVars   []any
+	Desc       bool
+	// Значение сортируемого поля и ID последней записи предыдущей страницы
+	Cursor *PageCursor
+}
+
+// Сортировка по релевантности поискового запроса
+const SortRelevance = "relevance"
+
+type PageCursor struct {
+	Value any
+	ID    uint
+}
+
+type PageResponse[T any] struct {
+	Items []T `json:"items"`
+	// Курсор следующей страницы, null - страница последняя
+	NextCursor *string `json:"next_cursor"`
+}
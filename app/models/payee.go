@@ -0,0 +1,45 @@
+package models
+
+import (
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// Получатель платежа (магазин, организация). Разные написания в названиях
+// транзакций сводятся к нему правилами
+type Payee struct {
+	gorm.Model
+	UserID uint
+	User   User `gorm:"foreignKey:UserID"`
+	Title  string
+	// Категория и бюджет списания по умолчанию для транзакций получателя
+	CategoryID    *sql.NullInt64
+	CategoryModel Category `gorm:"foreignKey:CategoryID"`
+	BudgetID      *sql.NullInt64
+	BudgetModel   Budget `gorm:"foreignKey:BudgetID"`
+}
+
+func (p Payee) TableName() string {
+	return "payees"
+}
+
+type PayeeStoreRequest struct {
+	Title      string `json:"title" validate:"required"`
+	CategoryID *uint  `json:"category_id"`
+	BudgetID   *uint  `json:"budget_id"`
+}
+
+// Отсутствующие поля не меняются, категорию и бюджет можно сбросить явным null
+type PayeePatchRequest struct {
+	Title      *string        `json:"title"`
+	CategoryID Optional[uint] `json:"category_id" swaggertype:"integer"`
+	BudgetID   Optional[uint] `json:"budget_id" swaggertype:"integer"`
+}
+
+type PayeeResponse struct {
+	ID         uint   `json:"id"`
+	Title      string `json:"title"`
+	CategoryID *uint  `json:"category_id"`
+	BudgetID   *uint  `json:"budget_id"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Способ сравнения названия транзакции с шаблоном правила
+type RuleMatch string
+
+const (
+	// Название содержит шаблон без учёта регистра
+	RuleMatchContains RuleMatch = "contains"
+	// Название соответствует регулярному выражению без учёта регистра
+	RuleMatchRegex RuleMatch = "regex"
+)
+
+// Правило категоризации: если транзакция подходит под все заданные условия
+// (шаблон названия, диапазон суммы), ей назначаются получатель, категория,
+// теги и бюджет. Правила проверяются по возрастанию Priority
+type Rule struct {
+	gorm.Model
+	UserID    uint
+	User      User `gorm:"foreignKey:UserID"`
+	Title     string
+	Priority  int
+	Match     RuleMatch
+	Pattern   string
+	MinAmount *decimal.Decimal
+	MaxAmount *decimal.Decimal
+	// Действия
+	PayeeID    *sql.NullInt64
+	CategoryID *sql.NullInt64
+	Tags       []Tag `gorm:"many2many:rule_tags;"`
+	// Бюджет назначается только транзакции без бюджетов,
+	// BudgetField определяет сторону: budget_from или budget_to
+	BudgetID    *sql.NullInt64
+	BudgetField string
+}
+
+func (r Rule) TableName() string {
+	return "rules"
+}
+
+// Правило заменяется целиком
+type RuleRequest struct {
+	Title    string `json:"title"`
+	Priority int    `json:"priority"`
+	// По умолчанию contains
+	Match     RuleMatch        `json:"match" validate:"omitempty,oneof=contains regex"`
+	Pattern   string           `json:"pattern" validate:"required_without_all=MinAmount MaxAmount"`
+	MinAmount *decimal.Decimal `json:"min_amount" validate:"omitempty,money" swaggertype:"string"`
+	MaxAmount *decimal.Decimal `json:"max_amount" validate:"omitempty,money" swaggertype:"string"`
+	// Действия, нужно хотя бы одно
+	PayeeID     *uint    `json:"payee_id" validate:"required_without_all=CategoryID Tags BudgetID"`
+	CategoryID  *uint    `json:"category_id"`
+	Tags        []string `json:"tags"`
+	BudgetID    *uint    `json:"budget_id"`
+	BudgetField string   `json:"budget_field" validate:"omitempty,oneof=budget_from budget_to"`
+}
+
+type RuleResponse struct {
+	ID          uint             `json:"id"`
+	Title       string           `json:"title"`
+	Priority    int              `json:"priority"`
+	Match       RuleMatch        `json:"match"`
+	Pattern     string           `json:"pattern"`
+	MinAmount   *decimal.Decimal `json:"min_amount" swaggertype:"string"`
+	MaxAmount   *decimal.Decimal `json:"max_amount" swaggertype:"string"`
+	PayeeID     *uint            `json:"payee_id"`
+	CategoryID  *uint            `json:"category_id"`
+	Tags        []string         `json:"tags"`
+	BudgetID    *uint            `json:"budget_id"`
+	BudgetField string           `json:"budget_field"`
+}
+
+type RuleApplyRequest struct {
+	// Перезаписывать получателя и категорию, уже заданные у транзакции
+	Overwrite bool `json:"overwrite"`
+}
+
+type RuleApplyResponse struct {
+	// Транзакции, подошедшие хотя бы под одно правило
+	Matched int `json:"matched"`
+	// Транзакции, у которых что-то изменилось
+	Updated int `json:"updated"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "gorm.io/gorm"
+
+type TagResponse struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+type TagRenameRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type TagMergeRequest struct {
+	// Тег, в который вливается текущий
+	TargetID uint `json:"target_id" validate:"required"`
+}
+
+type Tag struct {
+	gorm.Model
+	UserID uint
+	User   User `gorm:"foreignKey:UserID"`
+	Title  string
+}
+
+func (t Tag) TableName() string {
+	return "tags"
+}
+
+// Режимы фильтрации транзакций по тегам
+type TagsMatch string
+
+const (
+	TagsMatchAny TagsMatch = "any"
+	TagsMatchAll TagsMatch = "all"
+)
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Тип удалённой записи в корзине
+type TrashEntity string
+
+const (
+	TrashTrx       TrashEntity = "trx"
+	TrashBudget    TrashEntity = "budget"
+	TrashGoal      TrashEntity = "goal"
+	TrashGenerator TrashEntity = "generator"
+)
+
+// Удалённая запись любого типа
+type TrashRecord struct {
+	Entity    TrashEntity
+	ID        uint
+	Title     string
+	DeletedAt time.Time
+}
+
+type TrashItemResponse struct {
+	Entity    TrashEntity `json:"entity"`
+	ID        uint        `json:"id"`
+	Title     string      `json:"title"`
+	DeletedAt string      `json:"deleted_at"`
+	// Дата, после которой запись будет удалена окончательно
+	PurgeAt string `json:"purge_at"`
+}
+
+type TrashPurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
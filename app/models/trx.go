@@ -2,32 +2,148 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
+// Статус сверки транзакции с выпиской банка
+type TrxStatus string
+
+const (
+	// Ещё не прошла по счёту
+	TrxStatusPending TrxStatus = "pending"
+	// Прошла по счёту
+	TrxStatusCleared TrxStatus = "cleared"
+	// Сверена с выпиской, изменяется только после разблокировки
+	TrxStatusReconciled TrxStatus = "reconciled"
+)
+
+// Вид транзакции, определяется её бюджетами
+type TrxType string
+
+const (
+	// Только бюджет зачисления
+	TrxTypeIncome TrxType = "income"
+	// Только бюджет списания
+	TrxTypeExpense TrxType = "expense"
+	// Перевод между своими бюджетами, не входит в доходы и расходы
+	TrxTypeTransfer TrxType = "transfer"
+	// Разделённая транзакция, части которой разных видов
+	TrxTypeSplit TrxType = "split"
+)
+
+var ErrTrxNoBudget = errors.New("trx has neither budget_from nor budget_to")
+
+// Вид транзакции по наличию бюджетов списания и зачисления.
+// Транзакция без бюджетов ошибочна
+func TrxTypeOf(budgetFrom, budgetTo *sql.NullInt64) (TrxType, error) {
+	hasFrom := budgetFrom != nil && budgetFrom.Valid
+	hasTo := budgetTo != nil && budgetTo.Valid
+	switch {
+	case hasFrom && hasTo:
+		return TrxTypeTransfer, nil
+	case hasTo:
+		return TrxTypeIncome, nil
+	case hasFrom:
+		return TrxTypeExpense, nil
+	}
+	return "", ErrTrxNoBudget
+}
+
 type TrxRequest struct {
-	Title      string  `json:"title"`
-	Date       string  `json:"date" validate:"required"`
-	Amount     float64 `json:"amount" validate:"required,numeric"`
-	BudgetFrom *uint   `json:"budget_from"`
-	BudgetTo   *uint   `json:"budget_to"`
+	Title string `json:"title"`
+	Note  string `json:"note"`
+	Date  string `json:"date" validate:"required"`
+	// Суммы передаются десятичной строкой, например "1500.50", чтобы не терять
+	// копейки на float. Число в запросе тоже принимается
+	Amount     decimal.Decimal `json:"amount" validate:"nonzero,money" swaggertype:"string" example:"1500.50"`
+	BudgetFrom *uint           `json:"budget_from"`
+	BudgetTo   *uint           `json:"budget_to"`
+	CategoryID *uint           `json:"category_id"`
+	Tags       []string        `json:"tags"`
+	// По умолчанию cleared. reconciled ставится только сверкой бюджета
+	Status TrxStatus `json:"status" validate:"omitempty,oneof=pending cleared"`
+	// Если не задан, определяется правилами
+	PayeeID *uint `json:"payee_id"`
+	// Валюта определяется бюджетом, для транзакции без бюджетов - по умолчанию RUB
+	Currency string `json:"currency" validate:"omitempty,iso4217"`
+	// Сумма зачисления в валюте budget_to при переводе между бюджетами в разных валютах.
+	// Если не задана, пересчитывается по курсу на дату транзакции
+	DestAmount *decimal.Decimal `json:"dest_amount" validate:"omitempty,positive,money" swaggertype:"string"`
+	// Части разделённой транзакции, их суммы должны давать Amount.
+	// Бюджеты задаются в каждой части, а не в самой транзакции
+	Legs []TrxLegRequest `json:"legs" validate:"omitempty,min=2,dive"`
+	// Не создавать транзакцию, если уже есть похожая, а вернуть 409 с похожими
+	CheckDuplicates bool `json:"check_duplicates"`
+}
+
+type TrxLegRequest struct {
+	Note       string          `json:"note"`
+	Amount     decimal.Decimal `json:"amount" validate:"nonzero,money" swaggertype:"string"`
+	BudgetFrom *uint           `json:"budget_from"`
+	BudgetTo   *uint           `json:"budget_to"`
+}
+
+type TrxSplitRequest struct {
+	Legs []TrxLegRequest `json:"legs" validate:"required,min=2,dive"`
 }
 
 type TrxResponse struct {
-	ID         uint    `json:"id"`
-	Title      string  `json:"title"`
-	Date       string  `json:"date"`
-	Amount     float64 `json:"amount"`
-	BudgetFrom *uint   `json:"budget_from"`
-	BudgetTo   *uint   `json:"budget_to"`
+	ID         uint            `json:"id"`
+	Title      string          `json:"title"`
+	Note       string          `json:"note"`
+	Date       string          `json:"date"`
+	Amount     decimal.Decimal `json:"amount" swaggertype:"string"`
+	BudgetFrom *uint           `json:"budget_from"`
+	BudgetTo   *uint           `json:"budget_to"`
+	CategoryID *uint           `json:"category_id"`
+	Tags       []string        `json:"tags"`
+	PayeeID    *uint           `json:"payee_id"`
+	Status     TrxStatus       `json:"status"`
+	Type       TrxType         `json:"type"`
+	Currency   string          `json:"currency"`
+	// Только для переводов между бюджетами в разных валютах
+	DestAmount   *decimal.Decimal `json:"dest_amount,omitempty" swaggertype:"string"`
+	DestCurrency string           `json:"dest_currency,omitempty"`
+	// Части разделённой транзакции
+	Legs []TrxLegResponse `json:"legs,omitempty"`
+	// Участник общего бюджета, создавший транзакцию
+	CreatedBy uint `json:"created_by"`
+}
+
+type TrxLegResponse struct {
+	ID           uint             `json:"id"`
+	Note         string           `json:"note"`
+	Amount       decimal.Decimal  `json:"amount" swaggertype:"string"`
+	BudgetFrom   *uint            `json:"budget_from"`
+	BudgetTo     *uint            `json:"budget_to"`
+	Status       TrxStatus        `json:"status"`
+	Type         TrxType          `json:"type"`
+	DestAmount   *decimal.Decimal `json:"dest_amount,omitempty" swaggertype:"string"`
+	DestCurrency string           `json:"dest_currency,omitempty"`
 }
 
+// Отсутствующие поля не меняются. Бюджеты и категорию можно сбросить
+// явным null, сумма может быть нулевой
 type TrxPatchRequest struct {
-	Title  string  `json:"title"`
-	Amount float64 `json:"amount"`
+	Title      *string                   `json:"title"`
+	Note       *string                   `json:"note"`
+	Date       Optional[string]          `json:"date" swaggertype:"string"`
+	Amount     Optional[decimal.Decimal] `json:"amount" validate:"omitempty,money" swaggertype:"string"`
+	BudgetFrom Optional[uint]            `json:"budget_from" swaggertype:"integer"`
+	BudgetTo   Optional[uint]            `json:"budget_to" swaggertype:"integer"`
+	CategoryID Optional[uint]            `json:"category_id" swaggertype:"integer"`
+	PayeeID    Optional[uint]            `json:"payee_id" swaggertype:"integer"`
+	// Сверенную транзакцию нужно сначала разблокировать
+	Status *TrxStatus `json:"status" validate:"omitempty,oneof=pending cleared"`
+	// Сумма зачисления для перевода между валютами. Если меняется только Amount,
+	// пересчитывается по курсу
+	DestAmount *decimal.Decimal `json:"dest_amount" validate:"omitempty,positive,money" swaggertype:"string"`
+	// nil - теги не меняются, пустой массив - теги удаляются
+	Tags *[]string `json:"tags"`
 }
 
 type Trx struct {
@@ -35,14 +151,73 @@ type Trx struct {
 	UserID          uint
 	User            User `gorm:"foreignKey:UserID"`
 	Title           string
+	Note            string
 	Date            time.Time
 	Amount          decimal.Decimal `sql:"type:decimal(20,2);"`
 	BudgetFrom      *sql.NullInt64
 	BudgetToModel   Budget `gorm:"foreignKey:BudgetTo"`
 	BudgetTo        *sql.NullInt64
 	BudgetFromModel Budget `gorm:"foreignKey:BudgetFrom"`
+	CategoryID      *sql.NullInt64
+	CategoryModel   Category `gorm:"foreignKey:CategoryID"`
+	Tags            []Tag    `gorm:"many2many:transaction_tags;"`
+	PayeeID         *sql.NullInt64
+	PayeeModel      Payee     `gorm:"foreignKey:PayeeID"`
+	Status          TrxStatus `gorm:"size:16;not null;default:cleared"`
+	// Заполняется репозиторием по бюджетам, у разделённой транзакции - по бюджетам частей
+	Type TrxType `gorm:"size:16;index"`
+	// Валюта Amount: валюта бюджета списания, а без него - бюджета зачисления
+	Currency string `gorm:"size:3;not null;default:RUB"`
+	// Сумма зачисления в валюте BudgetTo, заполняется только
+	// при переводе между бюджетами в разных валютах
+	DestAmount   *decimal.Decimal
+	DestCurrency string `gorm:"size:3"`
+	// Разделённая транзакция не меняет бюджеты сама,
+	// их меняют её части (Legs), ссылающиеся на неё через ParentID
+	IsSplit  bool `gorm:"not null;default:false"`
+	ParentID *uint
+	Legs     []Trx `gorm:"foreignKey:ParentID"`
+	// Ранг полнотекстового поиска, заполняется только при поиске
+	Rank float64 `gorm:"column:search_rank;->;-:migration"`
 }
 
 func (t Trx) TableName() string {
 	return "transactions"
 }
+
+// Вид транзакции по её бюджетам. Разделённая транзакция получает вид
+// своих частей, если он у них общий, иначе - split
+func (t Trx) DeriveType() (TrxType, error) {
+	if !t.IsSplit || len(t.Legs) == 0 {
+		return TrxTypeOf(t.BudgetFrom, t.BudgetTo)
+	}
+	var trxType TrxType
+	for _, leg := range t.Legs {
+		legType, err := leg.DeriveType()
+		if err != nil {
+			return "", err
+		}
+		if trxType != "" && legType != trxType {
+			return TrxTypeSplit, nil
+		}
+		trxType = legType
+	}
+	return trxType, nil
+}
+
+// Фильтры списка транзакций
+type TrxFilter struct {
+	DateFrom    time.Time
+	DateTo      time.Time
+	MinAmount   decimal.Decimal
+	MaxAmount   decimal.Decimal
+	CategoryIDs []uint
+	// Только транзакции без категории
+	NoCategory bool
+	Tags       []string
+	TagsMatch  TagsMatch
+	Statuses   []TrxStatus
+	Types      []TrxType
+	// Поисковый запрос по названию и заметке
+	Query string
+}
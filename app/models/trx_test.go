@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func budget(id int64) *sql.NullInt64 {
+	return &sql.NullInt64{Int64: id, Valid: true}
+}
+
+func TestTrxTypeOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to *sql.NullInt64
+		want     TrxType
+		wantErr  error
+	}{
+		{"income", nil, budget(1), TrxTypeIncome, nil},
+		{"expense", budget(1), nil, TrxTypeExpense, nil},
+		{"transfer", budget(1), budget(2), TrxTypeTransfer, nil},
+		{"null budgets", &sql.NullInt64{}, &sql.NullInt64{}, "", ErrTrxNoBudget},
+		{"no budgets", nil, nil, "", ErrTrxNoBudget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TrxTypeOf(tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrxDeriveType(t *testing.T) {
+	expense := Trx{BudgetFrom: budget(1)}
+	income := Trx{BudgetTo: budget(1)}
+	transfer := Trx{BudgetFrom: budget(1), BudgetTo: budget(2)}
+
+	tests := []struct {
+		name    string
+		trx     Trx
+		want    TrxType
+		wantErr error
+	}{
+		{"plain trx", transfer, TrxTypeTransfer, nil},
+		{"split without legs", Trx{IsSplit: true, BudgetTo: budget(1)}, TrxTypeIncome, nil},
+		{"legs of one type", Trx{IsSplit: true, Legs: []Trx{expense, expense}}, TrxTypeExpense, nil},
+		{"transfer and expense legs", Trx{IsSplit: true, Legs: []Trx{transfer, expense}}, TrxTypeSplit, nil},
+		{"income and expense legs", Trx{IsSplit: true, Legs: []Trx{income, income, expense}}, TrxTypeSplit, nil},
+		{"leg without budgets", Trx{IsSplit: true, Legs: []Trx{expense, {}}}, "", ErrTrxNoBudget},
+		{"trx without budgets", Trx{}, "", ErrTrxNoBudget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.trx.DeriveType()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
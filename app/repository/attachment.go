@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+type AttachmentRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewAttachmentRepository(logger lib.Logger, db lib.Database) AttachmentRepository {
+	return AttachmentRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r AttachmentRepository) WithTrx(trxHandle *gorm.DB) AttachmentRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func (r AttachmentRepository) Create(attachment *models.Attachment) error {
+	return r.Database.Create(attachment).Error
+}
+
+func (r AttachmentRepository) ListOfTrx(trxID, userID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.Database.Where("trx_id = ? AND trx_id IN (?)", trxID, r.accessibleTrx(userID)).
+		Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r AttachmentRepository) Get(id, trxID, userID uint) (models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.Database.Where("id = ? AND trx_id = ? AND trx_id IN (?)", id, trxID, r.accessibleTrx(userID)).
+		First(&attachment).Error
+	return attachment, err
+}
+
+// Мягкое удаление: файл удаляется сервисом после фиксации транзакции,
+// запись остаётся до очистки корзины с транзакцией
+func (r AttachmentRepository) Delete(id, userID uint) error {
+	return r.Database.Where("id = ? AND user_id = ?", id, userID).
+		Delete(&models.Attachment{}).Error
+}
+
+// Вложения общей транзакции видны всем участникам её бюджетов
+func (r AttachmentRepository) accessibleTrx(userID uint) *gorm.DB {
+	return trxAccess(r.Database.Session(&gorm.Session{NewDB: true}).Model(&models.Trx{}).Select("id"), userID)
+}
+
+// Переносит вложения на другую транзакцию вместе с вложениями
+// других участников общего бюджета
+func (r AttachmentRepository) MoveToTrx(fromID, toID uint) error {
+	return r.Database.Model(&models.Attachment{}).
+		Where("trx_id = ?", fromID).
+		Update("trx_id", toID).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"time"
 
@@ -34,16 +35,34 @@ func (r BudgetRepository) WithTrx(trxHandle *gorm.DB) BudgetRepository {
 
 func (r BudgetRepository) List(userID uint) ([]models.Budget, error) {
 	var budgets []models.Budget
-	err := r.Database.Where("user_id = ?", userID).Find(&budgets).Error
+	err := budgetAccess(r.Database.DB, userID).Find(&budgets).Error
 	if err != nil {
 		return nil, err
 	}
 	return budgets, err
 }
 
+// Страница доступных пользователю бюджетов, архивные - только с archived
+func (r BudgetRepository) ListPage(userID uint, archived bool, page models.PageRequest) ([]models.Budget, error) {
+	var budgets []models.Budget
+	query := budgetAccess(r.Database.DB, userID)
+	if !archived {
+		query = query.Where("archived_at IS NULL")
+	}
+	err := paginate(query, page).Find(&budgets).Error
+	return budgets, err
+}
+
+// Доступные пользователю бюджеты, вложенные непосредственно в parentIDs
+func (r BudgetRepository) Children(parentIDs []uint, userID uint) ([]models.Budget, error) {
+	var budgets []models.Budget
+	err := budgetAccess(r.Database.DB, userID).Where("parent_id IN ?", parentIDs).Order("id").Find(&budgets).Error
+	return budgets, err
+}
+
 func (r BudgetRepository) ListOfGoal(userID uint, goalID uint) ([]models.Budget, error) {
 	var budgets []models.Budget
-	err := r.Database.Where("user_id = ? AND goal_id = ?", userID, goalID).Find(&budgets).Error
+	err := budgetAccess(r.Database.DB, userID).Where("goal_id = ?", goalID).Find(&budgets).Error
 	if err != nil {
 		return nil, err
 	}
@@ -52,108 +71,146 @@ func (r BudgetRepository) ListOfGoal(userID uint, goalID uint) ([]models.Budget,
 
 func (r BudgetRepository) Get(id uint, userID uint) (models.Budget, error) {
 	var budget models.Budget
-	err := r.Database.Where("user_id = ?", userID).Where("id = ?", id).First(&budget).Error
+	err := budgetAccess(r.Database.DB, userID).Where("id = ?", id).First(&budget).Error
 	return budget, err
 }
 
-// Получает сумму бюджета до определенной даты
-func (r BudgetRepository) GetBudgetAmount(budgetID, userID uint, date time.Time) (decimal.Decimal, error) {
-	var amount decimal.Decimal
-	err := r.Database.Model(&models.Trx{}).
-		Select("SUM(CASE WHEN budget_to = ? THEN CAST(amount AS DECIMAL) ELSE 0 END) - "+
-			"SUM(CASE WHEN budget_from = ? THEN CAST(amount AS DECIMAL) ELSE 0 END)", budgetID, budgetID).
-		Where("user_id = ? AND date <= ?", userID, date).
-		Group("user_id").
-		Row().
-		Scan(&amount)
+// Роль пользователя в бюджете. Создатель бюджета без записи об участии - владелец
+func (r BudgetRepository) Role(id, userID uint) (models.BudgetRole, error) {
+	budget, err := r.Get(id, userID)
 	if err != nil {
+		return "", err
+	}
+
+	var members []models.BudgetMember
+	if err := r.Database.Where("budget_id = ? AND user_id = ? AND accepted_at IS NOT NULL", id, userID).
+		Limit(1).Find(&members).Error; err != nil {
+		return "", err
+	}
+	if len(members) > 0 {
+		return members[0].Role, nil
+	}
+	if budget.UserID == userID {
+		return models.BudgetRoleOwner, nil
+	}
+	return "", gorm.ErrRecordNotFound
+}
+
+// Получает сумму бюджета до определенной даты.
+// При переводе между валютами зачисление учитывается в валюте бюджета (dest_amount).
+// В общем бюджете учитываются транзакции всех участников.
+// Остаток берётся из последнего снимка до месяца даты, к нему добавляется
+// движение после снимка. Без снимков остаток считается по всем движениям.
+// Снимки здесь не создаются: их пишут изменения транзакций и генераторов
+// и команда balance:rebuild
+func (r BudgetRepository) GetBudgetAmount(budgetID, userID uint, date time.Time) (decimal.Decimal, error) {
+	if date.IsZero() {
+		return decimal.Decimal{}, nil
+	}
+	if _, err := r.Get(budgetID, userID); err != nil {
 		return decimal.Decimal{}, err
 	}
 
+	month := monthStart(date)
 	var (
-		genTo   []models.Generator
-		genFrom []models.Generator
+		snapshot models.BudgetBalance
+		from     time.Time
 	)
-	if err := r.Database.Where("user_id = ? AND budget_to = ?",
-		userID,
-		budgetID).Find(&genTo).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return decimal.Decimal{}, err
+	res := r.Database.Where("budget_id = ? AND month < ?", budgetID, month).
+		Order("month DESC").Limit(1).Find(&snapshot)
+	if res.Error != nil {
+		return decimal.Decimal{}, res.Error
+	}
+	if res.RowsAffected > 0 {
+		from = monthStart(snapshot.Month).AddDate(0, 1, 0)
 	}
 
-	for _, gen := range genTo {
-		var (
-			currDate = gen.DateFrom
-			lastDate time.Time
-			dayAdd   int
-			monthAdd int
-			yearAdd  int
-		)
+	changes, err := budgetMovements(r.Database.DB, budgetID, from, date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	amount := snapshot.Balance
+	for _, change := range changes {
+		amount = amount.Add(change)
+	}
+	return amount, nil
+}
 
-		switch gen.Periodicity {
-		case models.PeriodicityDaily:
-			dayAdd = int(gen.PeriodicityFactor)
-		case models.PeriodicityMonthly:
-			monthAdd = int(gen.PeriodicityFactor)
-		case models.PeriodicityYearly:
-			yearAdd = int(gen.PeriodicityFactor)
-		}
+// Пересчитывает снимки остатков бюджетов заново по прошедшие месяцы,
+// без budgetIDs - всех бюджетов
+func (r BudgetRepository) RebuildBalances(budgetIDs ...uint) (models.BudgetBalanceRebuildResponse, error) {
+	var resp models.BudgetBalanceRebuildResponse
 
-		if gen.DateTo.Time.IsZero() || gen.DateTo.Time.After(date) {
-			lastDate = date
-		} else {
-			lastDate = gen.DateTo.Time
-		}
-
-		for currDate.Before(lastDate) || currDate.Equal(lastDate) {
-			amount = amount.Add(gen.Amount)
-			currDate = currDate.AddDate(yearAdd, monthAdd, dayAdd)
-		}
+	query := r.Database.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if len(budgetIDs) > 0 {
+		query = query.Where("budget_id IN ?", budgetIDs)
+	} else if err := r.Database.Model(&models.Budget{}).Order("id").Pluck("id", &budgetIDs).Error; err != nil {
+		return resp, err
 	}
-
-	if err := r.Database.Where("user_id = ? AND budget_from = ?",
-		userID,
-		budgetID).Find(&genFrom).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return decimal.Decimal{}, err
+	if err := query.Delete(&models.BudgetBalance{}).Error; err != nil {
+		return resp, err
 	}
-	for _, gen := range genFrom {
-		var (
-			currDate = gen.DateFrom
-			lastDate time.Time
-			dayAdd   int
-			monthAdd int
-			yearAdd  int
-		)
 
-		switch gen.Periodicity {
-		case models.PeriodicityDaily:
-			dayAdd = int(gen.PeriodicityFactor)
-		case models.PeriodicityMonthly:
-			monthAdd = int(gen.PeriodicityFactor)
-		case models.PeriodicityYearly:
-			yearAdd = int(gen.PeriodicityFactor)
+	for _, id := range budgetIDs {
+		count, err := ensureBalances(r.Database.DB, id, balancesUntil())
+		if err != nil {
+			return resp, err
 		}
+		resp.Budgets++
+		resp.Snapshots += count
+	}
+	return resp, nil
+}
 
-		if gen.DateTo.Time.IsZero() || gen.DateTo.Time.After(date) {
-			lastDate = date
-		} else {
-			lastDate = gen.DateTo.Time
+// Бюджеты, снимки которых расходятся с остатком, посчитанным заново
+// по транзакциям и генераторам, без budgetIDs - все бюджеты со снимками.
+// Снимки не меняются
+func (r BudgetRepository) CheckBalances(budgetIDs ...uint) ([]uint, error) {
+	if len(budgetIDs) == 0 {
+		if err := r.Database.Model(&models.BudgetBalance{}).Distinct().Order("budget_id").
+			Pluck("budget_id", &budgetIDs).Error; err != nil {
+			return nil, err
 		}
+	}
 
-		for currDate.Before(lastDate) || currDate.Equal(lastDate) {
-			amount = amount.Sub(gen.Amount)
-			currDate = currDate.AddDate(yearAdd, monthAdd, dayAdd)
+	var stale []uint
+	for _, id := range budgetIDs {
+		ok, err := checkBalances(r.Database.DB, id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			stale = append(stale, id)
 		}
 	}
+	return stale, nil
+}
 
-	return amount, nil
+// Изменение бюджета до даты транзакциями, ещё не прошедшими по счёту
+func (r BudgetRepository) GetPendingAmount(budgetID, userID uint, date time.Time) (decimal.Decimal, error) {
+	var amount decimal.NullDecimal
+	err := trxAccess(r.Database.Model(&models.Trx{}), userID).
+		Select("SUM(CASE WHEN budget_to = ? THEN CAST(COALESCE(dest_amount, amount) AS DECIMAL) ELSE 0 END) - "+
+			"SUM(CASE WHEN budget_from = ? THEN CAST(amount AS DECIMAL) ELSE 0 END)", budgetID, budgetID).
+		Where("date <= ? AND status = ?", date, models.TrxStatusPending).
+		Where("is_split = ?", false).
+		Row().
+		Scan(&amount)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return amount.Decimal, nil
 }
 
 func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo time.Time) ([]models.BudgetChanges, error) {
 	var changes []models.BudgetChanges
-	query := r.Database.Model(&models.Trx{}).Select("SUM(CASE WHEN budget_to = ? THEN CAST(amount AS DECIMAL) ELSE 0 END) - "+
-		"SUM(CASE WHEN budget_from = ? THEN CAST(amount AS DECIMAL) ELSE 0 END) as amount_change, date", budgetID, budgetID).
-		Where("user_id = ?", userID).
+	query := trxAccess(r.Database.Model(&models.Trx{}), userID).Select("SUM(CASE WHEN budget_to = ? THEN CAST(COALESCE(dest_amount, amount) AS DECIMAL) ELSE 0 END) - "+
+		"SUM(CASE WHEN budget_from = ? THEN CAST(amount AS DECIMAL) ELSE 0 END) as amount_change, "+
+		"SUM(CASE WHEN type = ? THEN CAST(amount AS DECIMAL) ELSE 0 END) as income, "+
+		"SUM(CASE WHEN type = ? THEN CAST(amount AS DECIMAL) ELSE 0 END) as expense, date",
+		budgetID, budgetID, models.TrxTypeIncome, models.TrxTypeExpense).
 		Where("budget_to = ? or budget_from = ?", budgetID, budgetID).
+		Where("is_split = ?", false).
 		Where("date > ?", dateFrom)
 	if !dateTo.IsZero() {
 		query.Where("date <= ?", dateTo)
@@ -174,13 +231,11 @@ func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo
 		genTo   []models.Generator
 		genFrom []models.Generator
 	)
-	if err := r.Database.Where("user_id = ? AND budget_to = ?",
-		userID,
+	if err := generatorAccess(r.Database.DB, userID).Where("budget_to = ?",
 		budgetID).Find(&genTo).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
-	if err := r.Database.Where("user_id = ? AND budget_from = ?",
-		userID,
+	if err := generatorAccess(r.Database.DB, userID).Where("budget_from = ?",
 		budgetID).Find(&genFrom).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
@@ -194,7 +249,7 @@ func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo
 			yearAdd  int
 		)
 
-		if !gen.DateTo.Time.IsZero() && dateTo.After(gen.DateTo.Time) {
+		if gen.DateTo != nil && !gen.DateTo.Time.IsZero() && dateTo.After(gen.DateTo.Time) {
 			lastDate = gen.DateTo.Time
 		} else {
 			lastDate = dateTo
@@ -214,7 +269,11 @@ func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo
 		}
 
 		for currDate.Before(lastDate) || currDate.Equal(lastDate) {
-			changes = append(changes, models.BudgetChanges{AmountChange: gen.Amount, Date: currDate})
+			change := models.BudgetChanges{AmountChange: gen.Amount, Date: currDate}
+			if genType, err := models.TrxTypeOf(gen.BudgetFrom, gen.BudgetTo); err == nil && genType == models.TrxTypeIncome {
+				change.Income = gen.Amount
+			}
+			changes = append(changes, change)
 			currDate = currDate.AddDate(yearAdd, monthAdd, dayAdd)
 		}
 	}
@@ -228,7 +287,7 @@ func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo
 			yearAdd  int
 		)
 
-		if !gen.DateTo.Time.IsZero() && dateTo.After(gen.DateTo.Time) {
+		if gen.DateTo != nil && !gen.DateTo.Time.IsZero() && dateTo.After(gen.DateTo.Time) {
 			lastDate = gen.DateTo.Time
 		} else {
 			lastDate = dateTo
@@ -248,7 +307,11 @@ func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo
 		}
 
 		for currDate.Before(lastDate) || currDate.Equal(lastDate) {
-			changes = append(changes, models.BudgetChanges{AmountChange: gen.Amount.Neg(), Date: currDate})
+			change := models.BudgetChanges{AmountChange: gen.Amount.Neg(), Date: currDate}
+			if genType, err := models.TrxTypeOf(gen.BudgetFrom, gen.BudgetTo); err == nil && genType == models.TrxTypeExpense {
+				change.Expense = gen.Amount
+			}
+			changes = append(changes, change)
 			currDate = currDate.AddDate(yearAdd, monthAdd, dayAdd)
 		}
 	}
@@ -256,23 +319,66 @@ func (r TrxRepository) GetBudgetChanges(budgetID, userID uint, dateFrom, dateTo
 	return changes, nil
 }
 
+// Создаёт бюджет, его создатель становится владельцем
 func (r BudgetRepository) Create(budget *models.Budget) error {
-	return r.Database.Create(&budget).Error
+	if err := r.Database.Create(&budget).Error; err != nil {
+		return err
+	}
+	if err := r.Database.Create(&models.BudgetMember{
+		BudgetID:   budget.ID,
+		UserID:     budget.UserID,
+		Role:       models.BudgetRoleOwner,
+		InvitedBy:  budget.UserID,
+		AcceptedAt: &sql.NullTime{Time: budget.CreatedAt, Valid: true},
+	}).Error; err != nil {
+		return err
+	}
+	return recordHistory(r.Database.DB, models.HistoryBudget, budget.ID, budget.UserID, nil)
 }
 
 func (r BudgetRepository) Patch(budget *models.Budget, id, userID uint) (models.Budget, error) {
 	var budgetResponse models.Budget
-	err := r.Database.Model(&budgetResponse).Where("user_id = ? AND id = ?", userID, id).Updates(&budget).Error
+	err := trackHistory(r.Database.DB, models.HistoryBudget, id, userID, func() error {
+		return budgetAccess(r.Database.Model(&budgetResponse), userID, models.BudgetWriteRoles...).
+			Where("id = ?", id).Updates(&budget).Error
+	})
 	if err != nil {
-		return models.Budget{}, nil
+		return models.Budget{}, err
 	}
 
-	if err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&budgetResponse).Error; err != nil {
+	if err := budgetAccess(r.Database.DB, userID).Where("id = ?", id).First(&budgetResponse).Error; err != nil {
 		return models.Budget{}, err
 	}
 	return budgetResponse, nil
 }
 
+// Задаёт лимит расходов бюджета из полей limit, пустой LimitAmount снимает лимит
+func (r BudgetRepository) SetLimit(limit models.Budget, id, userID uint) error {
+	return trackHistory(r.Database.DB, models.HistoryBudget, id, userID, func() error {
+		return budgetAccess(r.Database.Model(&models.Budget{}), userID, models.BudgetWriteRoles...).Where("id = ?", id).
+			Select("LimitAmount", "LimitPeriod", "LimitStart").
+			Updates(&limit).Error
+	})
+}
+
+// Переносит бюджет в родительский, пустой parent - на верхний уровень
+func (r BudgetRepository) SetParent(parent *sql.NullInt64, id, userID uint) error {
+	return trackHistory(r.Database.DB, models.HistoryBudget, id, userID, func() error {
+		return budgetAccess(r.Database.Model(&models.Budget{}), userID, models.BudgetWriteRoles...).Where("id = ?", id).
+			Update("parent_id", parent).Error
+	})
+}
+
+// Архивирует бюджет, пустой archivedAt возвращает его из архива
+func (r BudgetRepository) SetArchived(archivedAt *sql.NullTime, id, userID uint) error {
+	return trackHistory(r.Database.DB, models.HistoryBudget, id, userID, func() error {
+		return budgetAccess(r.Database.Model(&models.Budget{}), userID, models.BudgetWriteRoles...).Where("id = ?", id).
+			Update("archived_at", archivedAt).Error
+	})
+}
+
 func (r BudgetRepository) Delete(id uint, userID uint) error {
-	return r.Database.Where("user_id = ?", userID).Delete(&models.Budget{}, id).Error
+	return trackHistory(r.Database.DB, models.HistoryBudget, id, userID, func() error {
+		return budgetAccess(r.Database.DB, userID, models.BudgetRoleOwner).Delete(&models.Budget{}, id).Error
+	})
 }
@@ -0,0 +1,394 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"finapp/models"
+)
+
+// Движение по бюджетам: бюджет -> месяц -> сумма
+type balanceChanges map[uint]map[time.Time]decimal.Decimal
+
+func (c balanceChanges) add(budgetID *uint, date time.Time, amount decimal.Decimal) {
+	if budgetID == nil {
+		return
+	}
+	if c[*budgetID] == nil {
+		c[*budgetID] = make(map[time.Time]decimal.Decimal)
+	}
+	month := monthStart(date)
+	c[*budgetID][month] = c[*budgetID][month].Add(amount)
+}
+
+// Зачисление в бюджет - в валюте зачисления, списание - в валюте транзакции
+func (c balanceChanges) addTrx(from, to *uint, amount decimal.Decimal, destAmount *decimal.Decimal, date time.Time) {
+	c.add(from, date, amount.Neg())
+	if destAmount != nil {
+		amount = *destAmount
+	}
+	c.add(to, date, amount)
+}
+
+func (c balanceChanges) addGenerator(gen models.Generator, from, to time.Time) {
+	for _, date := range generatorDates(gen, from, to) {
+		c.addTrx(models.IDFromNull(gen.BudgetFrom), models.IDFromNull(gen.BudgetTo), gen.Amount, nil, date)
+	}
+}
+
+// Транзакция в снимке истории
+type trxBalanceState struct {
+	BudgetFrom *uint             `json:"budget_from"`
+	BudgetTo   *uint             `json:"budget_to"`
+	Amount     decimal.Decimal   `json:"amount"`
+	DestAmount *decimal.Decimal  `json:"dest_amount"`
+	Date       time.Time         `json:"date"`
+	IsSplit    bool              `json:"is_split"`
+	Legs       []trxBalanceState `json:"legs"`
+}
+
+func (s trxBalanceState) addTo(changes balanceChanges) {
+	// Разделённая транзакция учитывается по частям
+	if !s.IsSplit {
+		changes.addTrx(s.BudgetFrom, s.BudgetTo, s.Amount, s.DestAmount, s.Date)
+	}
+	for _, leg := range s.Legs {
+		leg.addTo(changes)
+	}
+}
+
+// Генератор в снимке истории
+type generatorBalanceState struct {
+	Amount            decimal.Decimal    `json:"amount"`
+	Periodicity       models.Periodicity `json:"periodicity"`
+	PeriodicityFactor uint               `json:"periodicity_factor"`
+	BudgetFrom        *int64             `json:"budget_from"`
+	BudgetTo          *int64             `json:"budget_to"`
+	DateFrom          time.Time          `json:"date_from"`
+	DateTo            *time.Time         `json:"date_to"`
+}
+
+func (s generatorBalanceState) generator() models.Generator {
+	gen := models.Generator{
+		Amount:            s.Amount,
+		Periodicity:       s.Periodicity,
+		PeriodicityFactor: s.PeriodicityFactor,
+		DateFrom:          s.DateFrom,
+	}
+	if s.BudgetFrom != nil {
+		gen.BudgetFrom = &sql.NullInt64{Int64: *s.BudgetFrom, Valid: true}
+	}
+	if s.BudgetTo != nil {
+		gen.BudgetTo = &sql.NullInt64{Int64: *s.BudgetTo, Valid: true}
+	}
+	if s.DateTo != nil {
+		gen.DateTo = &sql.NullTime{Time: *s.DateTo, Valid: true}
+	}
+	return gen
+}
+
+// Переносит изменение транзакции или генератора в снимки остатков
+// его бюджетов: вычитает движение по снимку до изменения и добавляет
+// движение по снимку после. Затем снимки досчитываются до прошлого месяца
+func updateBalances(db *gorm.DB, entity models.HistoryEntity, before, after *string) error {
+	if entity != models.HistoryTrx && entity != models.HistoryGenerator {
+		return nil
+	}
+
+	changes := make(balanceChanges)
+	for _, state := range []*string{before, after} {
+		if state == nil {
+			continue
+		}
+		stateChanges, err := stateBalanceChanges(entity, *state)
+		if err != nil {
+			return err
+		}
+		for budgetID, months := range stateChanges {
+			for month, amount := range months {
+				if state == before {
+					amount = amount.Neg()
+				}
+				changes.add(&budgetID, month, amount)
+			}
+		}
+	}
+
+	// Досчитанные снимки уже включают изменение, поэтому сначала
+	// меняются существующие снимки
+	for budgetID, months := range changes {
+		if err := applyBalanceChanges(db, budgetID, months); err != nil {
+			return err
+		}
+		if _, err := ensureBalances(db, budgetID, balancesUntil()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Движение по бюджетам записи из её снимка. Срабатывания генератора
+// учитываются до конца прошлого месяца, дальше снимков нет
+func stateBalanceChanges(entity models.HistoryEntity, state string) (balanceChanges, error) {
+	changes := make(balanceChanges)
+	if entity == models.HistoryTrx {
+		var trx trxBalanceState
+		if err := json.Unmarshal([]byte(state), &trx); err != nil {
+			return nil, err
+		}
+		trx.addTo(changes)
+		return changes, nil
+	}
+
+	var gen generatorBalanceState
+	if err := json.Unmarshal([]byte(state), &gen); err != nil {
+		return nil, err
+	}
+	changes.addGenerator(gen.generator(), time.Time{}, monthEnd(balancesUntil()))
+	return changes, nil
+}
+
+// Прибавляет движение по месяцам к снимкам бюджета: снимок месяца
+// меняется на сумму движения за этот и предыдущие месяцы
+func applyBalanceChanges(db *gorm.DB, budgetID uint, changes map[time.Time]decimal.Decimal) error {
+	var snapshots []models.BudgetBalance
+	if err := db.Where("budget_id = ?", budgetID).Order("month").Find(&snapshots).Error; err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+	first, last := monthStart(snapshots[0].Month), monthStart(snapshots[len(snapshots)-1].Month)
+
+	var minMonth time.Time
+	for month, amount := range changes {
+		if amount.IsZero() || month.After(last) {
+			continue
+		}
+		if minMonth.IsZero() || month.Before(minMonth) {
+			minMonth = month
+		}
+	}
+	if minMonth.IsZero() {
+		return nil
+	}
+
+	// Движение раньше первого снимка продлевает снимки назад
+	var prepended []models.BudgetBalance
+	for month := minMonth; month.Before(first); month = month.AddDate(0, 1, 0) {
+		prepended = append(prepended, models.BudgetBalance{BudgetID: budgetID, Month: month})
+	}
+	snapshots = append(prepended, snapshots...)
+
+	var running decimal.Decimal
+	for i := range snapshots {
+		running = running.Add(changes[monthStart(snapshots[i].Month)])
+		if running.IsZero() && snapshots[i].ID != 0 {
+			continue
+		}
+		snapshots[i].Balance = snapshots[i].Balance.Add(running)
+		if err := db.Save(&snapshots[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Досчитывает снимки бюджета до месяца until включительно,
+// начиная с последнего снимка или с первого движения по бюджету
+func ensureBalances(db *gorm.DB, budgetID uint, until time.Time) (int, error) {
+	var (
+		last models.BudgetBalance
+		from time.Time
+	)
+	res := db.Where("budget_id = ?", budgetID).Order("month DESC").Limit(1).Find(&last)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected > 0 {
+		if !monthStart(last.Month).Before(until) {
+			return 0, nil
+		}
+		from = monthStart(last.Month).AddDate(0, 1, 0)
+	} else {
+		first, err := firstBudgetMovement(db, budgetID)
+		if err != nil || first.IsZero() || first.After(until) {
+			return 0, err
+		}
+		from = first
+	}
+
+	changes, err := budgetMovements(db, budgetID, from, monthEnd(until))
+	if err != nil {
+		return 0, err
+	}
+
+	var snapshots []models.BudgetBalance
+	balance := last.Balance
+	for month := from; !month.After(until); month = month.AddDate(0, 1, 0) {
+		balance = balance.Add(changes[month])
+		snapshots = append(snapshots, models.BudgetBalance{BudgetID: budgetID, Month: month, Balance: balance})
+	}
+	// Параллельный запрос мог уже посчитать те же месяцы
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&snapshots, 100).Error; err != nil {
+		return 0, err
+	}
+	return len(snapshots), nil
+}
+
+// Сверяет снимки бюджета с движением по нему, посчитанным заново
+func checkBalances(db *gorm.DB, budgetID uint) (bool, error) {
+	var snapshots []models.BudgetBalance
+	if err := db.Where("budget_id = ?", budgetID).Order("month").Find(&snapshots).Error; err != nil {
+		return false, err
+	}
+	if len(snapshots) == 0 {
+		return true, nil
+	}
+
+	changes, err := budgetMovements(db, budgetID, time.Time{}, monthEnd(snapshots[len(snapshots)-1].Month))
+	if err != nil {
+		return false, err
+	}
+	// Движение до первого снимка входит в его остаток
+	var balance decimal.Decimal
+	first := monthStart(snapshots[0].Month)
+	for month, amount := range changes {
+		if month.Before(first) {
+			balance = balance.Add(amount)
+		}
+	}
+	for i, snapshot := range snapshots {
+		month := monthStart(snapshot.Month)
+		// Снимки идут подряд, пропущенный месяц - тоже расхождение
+		if !month.Equal(first.AddDate(0, i, 0)) {
+			return false, nil
+		}
+		balance = balance.Add(changes[month])
+		if !balance.Equal(snapshot.Balance) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Движение по бюджету по месяцам за даты [from, to], from пустая - с начала
+func budgetMovements(db *gorm.DB, budgetID uint, from, to time.Time) (map[time.Time]decimal.Decimal, error) {
+	changes := make(balanceChanges)
+
+	var trxs []models.Trx
+	query := db.Model(&models.Trx{}).Select("date", "amount", "dest_amount", "budget_from", "budget_to").
+		Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).
+		Where("is_split = ?", false).
+		Where("date <= ?", to)
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if err := query.Find(&trxs).Error; err != nil {
+		return nil, err
+	}
+	for _, trx := range trxs {
+		changes.addTrx(models.IDFromNull(trx.BudgetFrom), models.IDFromNull(trx.BudgetTo), trx.Amount, trx.DestAmount, trx.Date)
+	}
+
+	var gens []models.Generator
+	if err := db.Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).Find(&gens).Error; err != nil {
+		return nil, err
+	}
+	for _, gen := range gens {
+		changes.addGenerator(gen, from, to)
+	}
+
+	return changes[budgetID], nil
+}
+
+// Месяц первой транзакции или первого срабатывания генератора бюджета,
+// пустая дата, если движения нет
+func firstBudgetMovement(db *gorm.DB, budgetID uint) (time.Time, error) {
+	var first time.Time
+
+	var trxs []models.Trx
+	if err := db.Select("date").Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).
+		Where("is_split = ?", false).Order("date").Limit(1).Find(&trxs).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(trxs) > 0 {
+		first = trxs[0].Date
+	}
+
+	var gens []models.Generator
+	if err := db.Select("date_from").Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).
+		Order("date_from").Limit(1).Find(&gens).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(gens) > 0 && (first.IsZero() || gens[0].DateFrom.Before(first)) {
+		first = gens[0].DateFrom
+	}
+
+	if first.IsZero() {
+		return first, nil
+	}
+	return monthStart(first), nil
+}
+
+// Даты срабатываний генератора в интервале [from, to] с учётом его DateTo
+func generatorDates(gen models.Generator, from, to time.Time) []time.Time {
+	if gen.DateTo != nil && !gen.DateTo.Time.IsZero() && gen.DateTo.Time.Before(to) {
+		to = gen.DateTo.Time
+	}
+
+	var (
+		step     = int(max(gen.PeriodicityFactor, 1))
+		dayAdd   int
+		monthAdd int
+		yearAdd  int
+	)
+	switch gen.Periodicity {
+	case models.PeriodicityDaily:
+		dayAdd = step
+	case models.PeriodicityMonthly:
+		monthAdd = step
+	case models.PeriodicityYearly:
+		yearAdd = step
+	default:
+		return nil
+	}
+
+	currDate := gen.DateFrom
+	// Ежедневный генератор перематывается к from без перебора лет срабатываний
+	if dayAdd > 0 && currDate.Before(from) {
+		skip := int(from.Sub(currDate).Hours()/24) / dayAdd
+		currDate = currDate.AddDate(0, 0, skip*dayAdd)
+	}
+	for currDate.Before(from) {
+		currDate = currDate.AddDate(yearAdd, monthAdd, dayAdd)
+	}
+
+	var dates []time.Time
+	for !currDate.After(to) {
+		dates = append(dates, currDate)
+		currDate = currDate.AddDate(yearAdd, monthAdd, dayAdd)
+	}
+	return dates
+}
+
+// Последний месяц снимков - прошлый, текущий месяц считается по движению
+func balancesUntil() time.Time {
+	return monthStart(time.Now()).AddDate(0, -1, 0)
+}
+
+// Первое число месяца даты в UTC, ключ снимка
+func monthStart(date time.Time) time.Time {
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Последний день месяца
+func monthEnd(month time.Time) time.Time {
+	return monthStart(month).AddDate(0, 1, -1)
+}
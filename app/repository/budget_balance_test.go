@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+const testUserID = 1
+
+func newTestDatabase(t *testing.T) lib.Database {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Trx{}, &models.Budget{}, &models.Goal{}, &models.Generator{},
+		&models.Category{}, &models.Tag{}, &models.Payee{}, &models.History{}, &models.BudgetMember{},
+		&models.BudgetBalance{}, &models.Rule{}, &models.ImportedEntry{}, &models.Attachment{}); err != nil {
+		t.Fatal(err)
+	}
+	return lib.Database{DB: db}
+}
+
+func nullID(id uint) *sql.NullInt64 {
+	return &sql.NullInt64{Int64: int64(id), Valid: true}
+}
+
+// Первое число месяца, отстоящего от текущего на months
+func testMonth(months, day int) time.Time {
+	return monthStart(time.Now()).AddDate(0, months, day-1)
+}
+
+// Остаток по снимкам должен совпадать с остатком, посчитанным по всем движениям
+func assertBalances(t *testing.T, step string, budgets BudgetRepository, budgetIDs ...uint) {
+	t.Helper()
+	dates := []time.Time{testMonth(-20, 1), testMonth(-14, 15), testMonth(-6, 28), testMonth(-1, 1),
+		testMonth(0, 1), time.Now(), testMonth(3, 10)}
+	for _, budgetID := range budgetIDs {
+		for _, date := range dates {
+			got, err := budgets.GetBudgetAmount(budgetID, testUserID, date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes, err := budgetMovements(budgets.Database.DB, budgetID, time.Time{}, date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want decimal.Decimal
+			for _, change := range changes {
+				want = want.Add(change)
+			}
+			if !got.Equal(want) {
+				t.Errorf("%s: budget %d on %s: snapshot amount %s, live amount %s",
+					step, budgetID, date.Format(time.DateOnly), got, want)
+			}
+		}
+	}
+
+	stale, err := budgets.CheckBalances(budgetIDs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) > 0 {
+		t.Errorf("%s: stale snapshots of budgets %v", step, stale)
+	}
+}
+
+func TestBudgetBalancesFollowChanges(t *testing.T) {
+	db := newTestDatabase(t)
+	budgets := BudgetRepository{Database: db}
+	trxs := TrxRepository{Database: db}
+	generators := GeneratorRepository{database: db}
+	history := HistoryRepository{Database: db}
+
+	for i := 0; i < 2; i++ {
+		if err := db.Create(&models.Budget{UserID: testUserID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	income := models.Trx{UserID: testUserID, Amount: decimal.RequireFromString("100.10"),
+		BudgetTo: nullID(1), Date: testMonth(-12, 10)}
+	if err := trxs.Create(&income); err != nil {
+		t.Fatal(err)
+	}
+	destAmount := decimal.RequireFromString("7.50")
+	transfer := models.Trx{UserID: testUserID, Amount: decimal.RequireFromString("10"),
+		BudgetFrom: nullID(1), BudgetTo: nullID(2), DestAmount: &destAmount, Date: testMonth(-3, 5)}
+	if err := trxs.Create(&transfer); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&models.BudgetBalance{}).Count(&count)
+	if count == 0 {
+		t.Fatal("create: snapshots are not written")
+	}
+	assertBalances(t, "create", budgets, 1, 2)
+
+	if _, err := trxs.Patch(map[string]any{
+		"date":   testMonth(-18, 1),
+		"amount": decimal.RequireFromString("12.34"),
+	}, transfer.ID, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	assertBalances(t, "patch", budgets, 1, 2)
+
+	if err := trxs.Delete(transfer.ID, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	assertBalances(t, "delete", budgets, 1, 2)
+
+	versions, err := history.List(models.HistoryTrx, transfer.ID, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range versions {
+		if version.Version == 2 {
+			if err := history.Revert(models.HistoryTrx, transfer.ID, testUserID, version.After); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	assertBalances(t, "revert", budgets, 1, 2)
+
+	generator := models.Generator{UserID: testUserID, Amount: decimal.RequireFromString("5"),
+		Periodicity: models.PeriodicityMonthly, PeriodicityFactor: 1,
+		BudgetFrom: nullID(2), DateFrom: testMonth(-24, 3)}
+	if err := generators.Store(&generator); err != nil {
+		t.Fatal(err)
+	}
+	assertBalances(t, "generator create", budgets, 1, 2)
+
+	if _, err := generators.StopForBudget(2, testUserID, testMonth(-8, 1)); err != nil {
+		t.Fatal(err)
+	}
+	assertBalances(t, "generator stop", budgets, 1, 2)
+}
+
+func TestBudgetBalancesReadWithoutSnapshots(t *testing.T) {
+	db := newTestDatabase(t)
+	budgets := BudgetRepository{Database: db}
+
+	if err := db.Create(&models.Budget{UserID: testUserID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Запись мимо истории не обновляет снимки
+	if err := db.Create(&models.Trx{UserID: testUserID, Amount: decimal.RequireFromString("42"),
+		BudgetTo: nullID(1), Date: testMonth(-5, 1)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	amount, err := budgets.GetBudgetAmount(1, testUserID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !amount.Equal(decimal.RequireFromString("42")) {
+		t.Errorf("amount without snapshots = %s, want 42", amount)
+	}
+	var count int64
+	db.Model(&models.BudgetBalance{}).Count(&count)
+	if count != 0 {
+		t.Errorf("read wrote %d snapshots", count)
+	}
+
+	if _, err := budgets.RebuildBalances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Trx{UserID: testUserID, Amount: decimal.RequireFromString("2"),
+		BudgetFrom: nullID(1), Date: testMonth(-4, 1)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	stale, err := budgets.CheckBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0] != 1 {
+		t.Errorf("stale budgets = %v, want [1]", stale)
+	}
+
+	if _, err := budgets.RebuildBalances(1); err != nil {
+		t.Fatal(err)
+	}
+	assertBalances(t, "rebuild", budgets, 1)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+// Участники общих бюджетов и приглашения в них
+type BudgetMemberRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewBudgetMemberRepository(logger lib.Logger, db lib.Database) BudgetMemberRepository {
+	return BudgetMemberRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r BudgetMemberRepository) WithTrx(trxHandle *gorm.DB) BudgetMemberRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func (r BudgetMemberRepository) List(budgetID uint) ([]models.BudgetMember, error) {
+	var members []models.BudgetMember
+	err := r.Database.Preload("User").Where("budget_id = ?", budgetID).Order("id").Find(&members).Error
+	return members, err
+}
+
+// Непринятые приглашения пользователя
+func (r BudgetMemberRepository) Invitations(userID uint) ([]models.BudgetMember, error) {
+	var members []models.BudgetMember
+	err := r.Database.Preload("User").Where("user_id = ? AND accepted_at IS NULL", userID).
+		Order("id").Find(&members).Error
+	return members, err
+}
+
+func (r BudgetMemberRepository) Get(budgetID, userID uint) (models.BudgetMember, error) {
+	var member models.BudgetMember
+	err := r.Database.Preload("User").Where("budget_id = ? AND user_id = ?", budgetID, userID).First(&member).Error
+	return member, err
+}
+
+// Приглашает пользователя или меняет роль участника
+func (r BudgetMemberRepository) Invite(member *models.BudgetMember) error {
+	existing, err := r.Get(member.BudgetID, member.UserID)
+	switch {
+	case err == nil:
+		member.ID, member.AcceptedAt = existing.ID, existing.AcceptedAt
+		return r.Database.Model(&existing).Update("role", member.Role).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return r.Database.Create(member).Error
+	}
+	return err
+}
+
+func (r BudgetMemberRepository) Accept(budgetID, userID uint) error {
+	return r.Database.Model(&models.BudgetMember{}).
+		Where("budget_id = ? AND user_id = ? AND accepted_at IS NULL", budgetID, userID).
+		Update("accepted_at", sql.NullTime{Time: time.Now(), Valid: true}).Error
+}
+
+func (r BudgetMemberRepository) Delete(budgetID, userID uint) error {
+	return r.Database.Where("budget_id = ? AND user_id = ?", budgetID, userID).
+		Delete(&models.BudgetMember{}).Error
+}
+
+// Подзапрос бюджетов, в которых пользователь участвует с одной из ролей,
+// без ролей - с любой. Участие начинается с принятия приглашения
+func memberBudgets(db *gorm.DB, userID uint, roles ...models.BudgetRole) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true}).Model(&models.BudgetMember{}).
+		Select("budget_id").
+		Where("user_id = ? AND accepted_at IS NOT NULL", userID)
+	if len(roles) > 0 {
+		query = query.Where("role IN ?", roles)
+	}
+	return query
+}
+
+// Бюджеты, доступные пользователю: свои и общие
+func budgetAccess(db *gorm.DB, userID uint, roles ...models.BudgetRole) *gorm.DB {
+	return db.Where("(user_id = ? OR id IN (?))", userID, memberBudgets(db, userID, roles...))
+}
+
+// Генераторы, доступные пользователю: свои и генераторы общих бюджетов
+func generatorAccess(db *gorm.DB, userID uint, roles ...models.BudgetRole) *gorm.DB {
+	shared := memberBudgets(db, userID, roles...)
+	return db.Where("(user_id = ? OR budget_from IN (?) OR budget_to IN (?))", userID, shared, shared)
+}
+
+// Транзакции, доступные пользователю: свои, транзакции общих бюджетов
+// и разделённые транзакции, часть которых относится к общему бюджету
+func trxAccess(db *gorm.DB, userID uint, roles ...models.BudgetRole) *gorm.DB {
+	shared := memberBudgets(db, userID, roles...)
+	splits := db.Session(&gorm.Session{NewDB: true}).Model(&models.Trx{}).
+		Select("parent_id").
+		Where("parent_id IS NOT NULL AND (budget_from IN (?) OR budget_to IN (?))", shared, shared)
+	return db.Where("(user_id = ? OR budget_from IN (?) OR budget_to IN (?) OR id IN (?))",
+		userID, shared, shared, splits)
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+type CategoryRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewCategoryRepository(logger lib.Logger, db lib.Database) CategoryRepository {
+	return CategoryRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r CategoryRepository) WithTrx(trxHandle *gorm.DB) CategoryRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func (r CategoryRepository) Create(category *models.Category) error {
+	return r.Database.Create(&category).Error
+}
+
+func (r CategoryRepository) List(userID uint) ([]models.Category, error) {
+	var categories []models.Category
+	if err := r.Database.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r CategoryRepository) Get(id, userID uint) (models.Category, error) {
+	var category models.Category
+	err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&category).Error
+	return category, err
+}
+
+func (r CategoryRepository) Patch(category models.Category, id, userID uint) (models.Category, error) {
+	var updateCategory models.Category
+	if err := r.Database.Model(&updateCategory).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(&category).Error; err != nil {
+		return models.Category{}, err
+	}
+
+	if err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&updateCategory).Error; err != nil {
+		return models.Category{}, err
+	}
+	return updateCategory, nil
+}
+
+// Удаляет категорию и отвязывает от неё транзакции, получателей и правила
+func (r CategoryRepository) Delete(id, userID uint) error {
+	if err := unlinkTrxs(r.Database.DB, userID, "category_id", id); err != nil {
+		return err
+	}
+	for _, model := range []any{&models.Payee{}, &models.Rule{}} {
+		if err := r.Database.Model(model).
+			Where("user_id = ? AND category_id = ?", userID, id).
+			Update("category_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return r.Database.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Category{}).Error
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+type ExchangeRateRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewExchangeRateRepository(logger lib.Logger, db lib.Database) ExchangeRateRepository {
+	return ExchangeRateRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r ExchangeRateRepository) WithTrx(trxHandle *gorm.DB) ExchangeRateRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+// Сохраняет курсы, заменяя уже загруженные на ту же дату
+func (r ExchangeRateRepository) Upsert(rates []models.ExchangeRate) error {
+	return r.Database.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "user_id"}, {Name: "date"}, {Name: "base"}, {Name: "quote"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at", "deleted_at"}),
+	}).Create(&rates).Error
+}
+
+func (r ExchangeRateRepository) List(userID uint, dateFrom, dateTo time.Time) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	query := r.Database.Where("user_id = ?", userID)
+	if !dateFrom.IsZero() {
+		query = query.Where("date >= ?", dateFrom)
+	}
+	if !dateTo.IsZero() {
+		query = query.Where("date <= ?", dateTo)
+	}
+	err := query.Order("date, base, quote").Find(&rates).Error
+	return rates, err
+}
+
+// Курс base к quote на последнюю известную дату не позже date.
+// Если прямого курса нет, используется обратный
+func (r ExchangeRateRepository) GetRate(userID uint, base, quote string, date time.Time) (decimal.Decimal, error) {
+	var rate models.ExchangeRate
+	err := r.Database.Where("user_id = ? AND date <= ?", userID, date).
+		Where("(base = ? AND quote = ?) OR (base = ? AND quote = ?)", base, quote, quote, base).
+		Order("date DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s/%s on %s", ErrExchangeRateNotFound, base, quote, date.Format("2006-01-02"))
+	}
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	if rate.Base == base {
+		return rate.Rate, nil
+	}
+	return decimal.NewFromInt(1).DivRound(rate.Rate, 8), nil
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"database/sql"
+	"time"
+
 	"finapp/lib"
 	"finapp/models"
 
@@ -32,12 +35,15 @@ func (r GeneratorRepository) WithTrx(trxHandle *gorm.DB) GeneratorRepository {
 }
 
 func (r GeneratorRepository) Store(generator *models.Generator) error {
-	return r.database.Create(&generator).Error
+	if err := r.database.Create(&generator).Error; err != nil {
+		return err
+	}
+	return recordHistory(r.database.DB, models.HistoryGenerator, generator.ID, generator.UserID, nil)
 }
 
-func (r GeneratorRepository) List(userID uint) ([]models.Generator, error) {
+func (r GeneratorRepository) List(userID uint, page models.PageRequest) ([]models.Generator, error) {
 	var generators []models.Generator
-	err := r.database.Where("user_id = ?", userID).Find(&generators).Error
+	err := paginate(generatorAccess(r.database.DB, userID), page).Find(&generators).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +51,10 @@ func (r GeneratorRepository) List(userID uint) ([]models.Generator, error) {
 	return generators, nil
 }
 
-func (r GeneratorRepository) Get(id, userID uint) (models.Generator, error) {
+// Генератор, доступный пользователю с одной из ролей в общих бюджетах, без ролей - с любой
+func (r GeneratorRepository) Get(id, userID uint, roles ...models.BudgetRole) (models.Generator, error) {
 	var generator models.Generator
-	err := r.database.Where("id = ? AND user_id = ?", id, userID).First(&generator).Error
+	err := generatorAccess(r.database.DB, userID, roles...).Where("id = ?", id).First(&generator).Error
 	if err != nil {
 		return models.Generator{}, err
 	}
@@ -57,13 +64,15 @@ func (r GeneratorRepository) Get(id, userID uint) (models.Generator, error) {
 
 func (r GeneratorRepository) Update(generator models.Generator, id, userID uint) (models.Generator, error) {
 	var genResponse models.Generator
-	if err := r.database.Model(&genResponse).
-		Where("id = ? AND user_id = ?", id, userID).
-		Updates(&generator).Error; err != nil {
+	if err := trackHistory(r.database.DB, models.HistoryGenerator, id, userID, func() error {
+		return generatorAccess(r.database.Model(&genResponse), userID, models.BudgetWriteRoles...).
+			Where("id = ?", id).
+			Updates(&generator).Error
+	}); err != nil {
 		return models.Generator{}, err
 	}
 
-	if err := r.database.Where("id = ? AND user_id = ?", id, userID).
+	if err := generatorAccess(r.database.DB, userID).Where("id = ?", id).
 		First(&genResponse).Error; err != nil {
 		return models.Generator{}, err
 	}
@@ -71,6 +80,31 @@ func (r GeneratorRepository) Update(generator models.Generator, id, userID uint)
 	return genResponse, nil
 }
 
+// Останавливает генераторы бюджета на дату date: они перестают создавать
+// суммы после неё. Возвращает число остановленных генераторов
+func (r GeneratorRepository) StopForBudget(budgetID, userID uint, date time.Time) (int64, error) {
+	var generators []models.Generator
+	if err := generatorAccess(r.database.DB, userID, models.BudgetWriteRoles...).
+		Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).
+		Where("date_to IS NULL OR date_to > ?", date).
+		Find(&generators).Error; err != nil {
+		return 0, err
+	}
+
+	for _, generator := range generators {
+		if err := trackHistory(r.database.DB, models.HistoryGenerator, generator.ID, userID, func() error {
+			return r.database.Model(&models.Generator{}).Where("id = ?", generator.ID).
+				Update("date_to", sql.NullTime{Time: date, Valid: true}).Error
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(generators)), nil
+}
+
 func (r GeneratorRepository) Delete(id, userID uint) error {
-	return r.database.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Generator{}).Error
+	return trackHistory(r.database.DB, models.HistoryGenerator, id, userID, func() error {
+		return generatorAccess(r.database.DB, userID, models.BudgetWriteRoles...).Where("id = ?", id).
+			Delete(&models.Generator{}).Error
+	})
 }
@@ -34,23 +34,28 @@ func (r GoalRepository) Get(id, userID uint) (models.Goal, error) {
 	return goal, err
 }
 
-func (r GoalRepository) List(userID uint) ([]models.Goal, error) {
+func (r GoalRepository) List(userID uint, page models.PageRequest) ([]models.Goal, error) {
 	var goals []models.Goal
-	if err := r.Database.Where("user_id = ?", userID).Find(&goals).Error; err != nil {
+	if err := paginate(r.Database.Where("user_id = ?", userID), page).Find(&goals).Error; err != nil {
 		return nil, err
 	}
 	return goals, nil
 }
 
 func (r GoalRepository) Create(goal *models.Goal) error {
-	return r.Database.Create(&goal).Error
+	if err := r.Database.Create(&goal).Error; err != nil {
+		return err
+	}
+	return recordHistory(r.Database.DB, models.HistoryGoal, goal.ID, goal.UserID, nil)
 }
 
 func (r GoalRepository) Patch(goal models.Goal, id, userID uint) (models.Goal, error) {
 	var updateGoal models.Goal
-	err := r.Database.Model(&updateGoal).Where("user_id = ? AND id = ?", userID, id).Updates(&goal).Error
+	err := trackHistory(r.Database.DB, models.HistoryGoal, id, userID, func() error {
+		return r.Database.Model(&updateGoal).Where("user_id = ? AND id = ?", userID, id).Updates(&goal).Error
+	})
 	if err != nil {
-		return models.Goal{}, nil
+		return models.Goal{}, err
 	}
 
 	if err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&updateGoal).Error; err != nil {
@@ -60,5 +65,7 @@ func (r GoalRepository) Patch(goal models.Goal, id, userID uint) (models.Goal, e
 }
 
 func (r GoalRepository) Delete(id uint, userID uint) error {
-	return r.Database.Where("user_id = ?", userID).Delete(&models.Goal{}, id).Error
+	return trackHistory(r.Database.DB, models.HistoryGoal, id, userID, func() error {
+		return r.Database.Where("user_id = ?", userID).Delete(&models.Goal{}, id).Error
+	})
 }
@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+// История изменений транзакций, бюджетов, целей и генераторов.
+// Версии пишут сами репозитории при создании, изменении и удалении записей
+type HistoryRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewHistoryRepository(logger lib.Logger, db lib.Database) HistoryRepository {
+	return HistoryRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r HistoryRepository) WithTrx(trxHandle *gorm.DB) HistoryRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+// Версии записи, сначала последние. Историю общей записи видят все её участники
+func (r HistoryRepository) List(entity models.HistoryEntity, id, userID uint) ([]models.History, error) {
+	owner, err := r.owner(entity, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []models.History
+	err = r.Database.Where("entity = ? AND record_id = ? AND user_id = ?", entity, id, owner).
+		Order("version DESC").Find(&history).Error
+	return history, err
+}
+
+func (r HistoryRepository) Get(entity models.HistoryEntity, id, version, userID uint) (models.History, error) {
+	owner, err := r.owner(entity, id, userID)
+	if err != nil {
+		return models.History{}, err
+	}
+
+	var history models.History
+	err = r.Database.Where("entity = ? AND record_id = ? AND version = ? AND user_id = ?", entity, id, version, owner).
+		First(&history).Error
+	return history, err
+}
+
+// Владелец записи, доступной пользователю с одной из ролей, в том числе удалённой
+func (r HistoryRepository) owner(entity models.HistoryEntity, id, userID uint, roles ...models.BudgetRole) (uint, error) {
+	model, err := historyModel(entity)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordAccess(r.Database.Unscoped(), entity, userID, roles...).Where("id = ?", id).
+		Take(model).Error; err != nil {
+		return 0, err
+	}
+	values, err := columns(r.Database.DB, model)
+	if err != nil {
+		return 0, err
+	}
+	owner, _ := values["user_id"].(uint)
+	return owner, nil
+}
+
+// Возвращает запись к состоянию state, сохранённому в истории.
+// Пустое состояние означает удаление, удалённая запись восстанавливается.
+// Окончательно удалённую из корзины запись вернуть нельзя
+func (r HistoryRepository) Revert(entity models.HistoryEntity, id, userID uint, state *string) error {
+	model, err := historyModel(entity)
+	if err != nil {
+		return err
+	}
+	owner, err := r.owner(entity, id, userID, models.BudgetWriteRoles...)
+	if err != nil {
+		return err
+	}
+	if err := r.Database.Unscoped().Where("id = ?", id).Take(model).Error; err != nil {
+		return err
+	}
+
+	before, err := snapshot(r.Database.DB, entity, id)
+	if err != nil {
+		return err
+	}
+
+	if state == nil {
+		if before == nil {
+			return nil
+		}
+		query := r.Database.Where("user_id = ? AND id = ?", owner, id)
+		if entity == models.HistoryTrx {
+			query = r.Database.Where("user_id = ? AND (id = ? OR parent_id = ?)", owner, id, id)
+		}
+		// Пустая модель, иначе gorm добавит условие по id загруженной записи
+		// и части транзакции не удалятся
+		empty, _ := historyModel(entity)
+		if err := query.Delete(empty).Error; err != nil {
+			return err
+		}
+		return recordHistory(r.Database.DB, entity, id, userID, before)
+	}
+
+	values, err := decodeSnapshot(*state)
+	if err != nil {
+		return err
+	}
+	if err := r.restoreColumns(model, values); err != nil {
+		return err
+	}
+	if entity == models.HistoryTrx {
+		if err := r.restoreTrxRelations(model.(*models.Trx), values); err != nil {
+			return err
+		}
+	}
+	return recordHistory(r.Database.DB, entity, id, userID, before)
+}
+
+// Записывает значения колонок в модель и сохраняет её, снимая пометку удаления
+func (r HistoryRepository) restoreColumns(model any, values map[string]any) error {
+	if err := setColumns(r.Database.DB, model, values); err != nil {
+		return err
+	}
+	return r.Database.Unscoped().Omit(clause.Associations).Save(model).Error
+}
+
+// Теги и части транзакции из снимка
+func (r HistoryRepository) restoreTrxRelations(trx *models.Trx, values map[string]any) error {
+	var tagIDs []uint
+	for _, v := range asSlice(values["tags"]) {
+		if id, ok := v.(int64); ok {
+			tagIDs = append(tagIDs, uint(id))
+		}
+	}
+	tags := make([]models.Tag, 0, len(tagIDs))
+	if len(tagIDs) > 0 {
+		if err := r.Database.Where("id IN ? AND user_id = ?", tagIDs, trx.UserID).Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+	if err := r.Database.Model(trx).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+
+	keep := []uint{0}
+	for _, v := range asSlice(values["legs"]) {
+		legValues, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		var leg models.Trx
+		if err := r.restoreColumns(&leg, legValues); err != nil {
+			return err
+		}
+		keep = append(keep, leg.ID)
+	}
+	return r.Database.Where("user_id = ? AND parent_id = ? AND id NOT IN ?", trx.UserID, trx.ID, keep).
+		Delete(&models.Trx{}).Error
+}
+
+// Записи сущности, доступные пользователю: бюджеты, их транзакции
+// и генераторы - также через участие в общих бюджетах
+func recordAccess(db *gorm.DB, entity models.HistoryEntity, userID uint, roles ...models.BudgetRole) *gorm.DB {
+	switch entity {
+	case models.HistoryBudget:
+		return budgetAccess(db, userID, roles...)
+	case models.HistoryTrx:
+		return trxAccess(db, userID, roles...)
+	case models.HistoryGenerator:
+		return generatorAccess(db, userID, roles...)
+	}
+	return db.Where("user_id = ?", userID)
+}
+
+// Сущности истории совпадают с сущностями корзины
+func historyModel(entity models.HistoryEntity) (any, error) {
+	return trashModel(models.TrashEntity(entity))
+}
+
+// Снимок записи в JSON: значения колонок, для транзакции также
+// идентификаторы тегов и части. nil, если записи нет или она удалена
+func snapshot(db *gorm.DB, entity models.HistoryEntity, id uint) (*string, error) {
+	model, err := historyModel(entity)
+	if err != nil {
+		return nil, err
+	}
+	res := db.Where("id = ?", id).Limit(1).Find(model)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+
+	values, err := columns(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	if entity == models.HistoryTrx {
+		var tagIDs []uint
+		if err := db.Table("transaction_tags").Where("trx_id = ?", id).Order("tag_id").
+			Pluck("tag_id", &tagIDs).Error; err != nil {
+			return nil, err
+		}
+		values["tags"] = tagIDs
+
+		var legs []models.Trx
+		if err := db.Where("parent_id = ?", id).Order("id").Find(&legs).Error; err != nil {
+			return nil, err
+		}
+		legValues := make([]map[string]any, 0, len(legs))
+		for i := range legs {
+			leg, err := columns(db, &legs[i])
+			if err != nil {
+				return nil, err
+			}
+			legValues = append(legValues, leg)
+		}
+		values["legs"] = legValues
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	state := string(data)
+	return &state, nil
+}
+
+// Выполняет изменение записи и записывает его в историю
+func trackHistory(db *gorm.DB, entity models.HistoryEntity, id, userID uint, change func() error) error {
+	before, err := snapshot(db, entity, id)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return recordHistory(db, entity, id, userID, before)
+}
+
+// Записывает версию записи: before - снимок до изменения, после изменения
+// снимок делается здесь. Изменения в рамках одного запроса дополняют его версию
+func recordHistory(db *gorm.DB, entity models.HistoryEntity, id, userID uint, before *string) error {
+	after, err := snapshot(db, entity, id)
+	if err != nil {
+		return err
+	}
+	if before == nil && after == nil || before != nil && after != nil && *before == *after {
+		return nil
+	}
+	// Снимки остатков бюджетов обновляются вместе с историей
+	if err := updateBalances(db, entity, before, after); err != nil {
+		return err
+	}
+
+	info := lib.RequestInfoFrom(db.Statement.Context)
+
+	// Версии общей записи принадлежат её владельцу, а не изменившему её участнику
+	owner := snapshotOwner(after)
+	if owner == 0 {
+		owner = snapshotOwner(before)
+	}
+	if owner == 0 {
+		owner = userID
+	}
+
+	var last []models.History
+	if err := db.Where("entity = ? AND record_id = ?", entity, id).
+		Order("version DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+
+	if len(last) > 0 && info.ID != "" && last[0].RequestID == info.ID {
+		version := last[0]
+		version.After = after
+		version.Action = historyAction(version.Before, after, version.Action == models.HistoryRestore)
+		return db.Model(&version).Select("After", "Action").Updates(&version).Error
+	}
+
+	version := models.History{
+		UserID:    owner,
+		Entity:    entity,
+		RecordID:  id,
+		Version:   1,
+		Action:    historyAction(before, after, len(last) > 0),
+		Before:    before,
+		After:     after,
+		ChangedBy: info.UserID,
+		RequestID: info.ID,
+	}
+	if len(last) > 0 {
+		version.Version = last[0].Version + 1
+	}
+	// Вне запроса (команды) изменение приписывается владельцу
+	if version.ChangedBy == 0 {
+		version.ChangedBy = userID
+	}
+	return db.Create(&version).Error
+}
+
+// Владелец записи по снимку, 0 для пустого снимка
+func snapshotOwner(state *string) uint {
+	if state == nil {
+		return 0
+	}
+	var values struct {
+		UserID uint `json:"user_id"`
+	}
+	if err := json.Unmarshal([]byte(*state), &values); err != nil {
+		return 0
+	}
+	return values.UserID
+}
+
+// Запись без снимка до изменения, но с прежними версиями, была в корзине
+func historyAction(before, after *string, hadVersions bool) models.HistoryAction {
+	switch {
+	case after == nil:
+		return models.HistoryDelete
+	case before != nil:
+		return models.HistoryUpdate
+	case hadVersions:
+		return models.HistoryRestore
+	}
+	return models.HistoryCreate
+}
+
+// Значения колонок модели в том виде, в каком они пишутся в базу
+func columns(db *gorm.DB, model any) (map[string]any, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(model).Elem()
+	values := make(map[string]any, len(stmt.Schema.Fields))
+	for _, field := range stmt.Schema.Fields {
+		// Вычисляемые при чтении колонки (ранг поиска) не сохраняются
+		if field.DBName == "" || !field.Creatable && !field.Updatable {
+			continue
+		}
+		value, _ := field.ValueOf(db.Statement.Context, rv)
+		if fv := reflect.ValueOf(value); fv.Kind() == reflect.Pointer && fv.IsNil() {
+			value = nil
+		} else if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		}
+		values[field.DBName] = value
+	}
+	return values, nil
+}
+
+// Записывает в модель значения колонок из снимка.
+// Колонки, которых не было на момент снимка, не меняются
+func setColumns(db *gorm.DB, model any, values map[string]any) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(model).Elem()
+	for _, field := range stmt.Schema.Fields {
+		value, ok := values[field.DBName]
+		if field.DBName == "" || !ok || !field.Creatable && !field.Updatable {
+			continue
+		}
+		// Время в JSON хранится строкой RFC 3339, в том числе в sql.NullTime и gorm.DeletedAt
+		if s, isString := value.(string); isString && field.IndirectFieldType.Kind() == reflect.Struct {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				value = t
+			}
+		}
+		if value == nil {
+			rv.FieldByIndex(field.StructField.Index).SetZero()
+			continue
+		}
+		if err := field.Set(db.Statement.Context, rv, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Числа снимка декодируются в int64, если они целые
+func decodeSnapshot(state string) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(state))
+	decoder.UseNumber()
+
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, errors.New("empty history snapshot")
+	}
+	return normalizeNumbers(values).(map[string]any), nil
+}
+
+func normalizeNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	}
+	return value
+}
+
+func asSlice(value any) []any {
+	items, _ := value.([]any)
+	return items
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"finapp/models"
+)
+
+func TestHistoryAction(t *testing.T) {
+	state := "{}"
+	tests := []struct {
+		name          string
+		before, after *string
+		hadVersions   bool
+		want          models.HistoryAction
+	}{
+		{"create", nil, &state, false, models.HistoryCreate},
+		{"update", &state, &state, true, models.HistoryUpdate},
+		{"delete", &state, nil, true, models.HistoryDelete},
+		{"restore from trash", nil, &state, true, models.HistoryRestore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := historyAction(tt.before, tt.after, tt.hadVersions); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Снимок без updated_at, который меняется при каждом сохранении
+func comparableSnapshot(t *testing.T, state *string) map[string]any {
+	t.Helper()
+	if state == nil {
+		return nil
+	}
+	values, err := decodeSnapshot(*state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(values, "updated_at")
+	for _, leg := range asSlice(values["legs"]) {
+		if leg, ok := leg.(map[string]any); ok {
+			delete(leg, "updated_at")
+		}
+	}
+	return values
+}
+
+func TestHistoryRevert(t *testing.T) {
+	db := newTestDatabase(t)
+	trxs := TrxRepository{Database: db}
+	history := HistoryRepository{Database: db}
+
+	for i := 0; i < 2; i++ {
+		if err := db.Create(&models.Budget{UserID: testUserID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tags := []models.Tag{{UserID: testUserID, Title: "a"}, {UserID: testUserID, Title: "b"}}
+	if err := db.Create(&tags).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	trx := models.Trx{UserID: testUserID, Title: "Market", Amount: decimal.RequireFromString("100"),
+		BudgetFrom: nullID(1), Date: testMonth(-2, 3), Tags: tags[:1]}
+	if err := trxs.Create(&trx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trxs.Patch(map[string]any{"title": "Supermarket"}, trx.ID, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	if err := trxs.ReplaceTags(&trx, tags[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := trxs.ReplaceLegs(&trx, []models.Trx{
+		{UserID: testUserID, Amount: decimal.RequireFromString("60"), BudgetFrom: nullID(1), Date: trx.Date},
+		{UserID: testUserID, Amount: decimal.RequireFromString("40"), BudgetFrom: nullID(1), BudgetTo: nullID(2), Date: trx.Date},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := trxs.Delete(trx.ID, testUserID); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := history.List(models.HistoryTrx, trx.ID, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make([]models.HistoryAction, 0, len(versions))
+	for _, version := range versions {
+		actions = append(actions, version.Action)
+	}
+	wantActions := []models.HistoryAction{models.HistoryDelete, models.HistoryUpdate, models.HistoryUpdate,
+		models.HistoryUpdate, models.HistoryCreate}
+	if !reflect.DeepEqual(actions, wantActions) {
+		t.Fatalf("actions = %v, want %v", actions, wantActions)
+	}
+
+	// Возврат к каждой версии, начиная с самой старой, восстанавливает её снимок целиком,
+	// включая теги и части
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		if err := history.Revert(models.HistoryTrx, trx.ID, testUserID, version.After); err != nil {
+			t.Fatalf("revert to version %d: %v", version.Version, err)
+		}
+		got, err := snapshot(db.DB, models.HistoryTrx, trx.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := comparableSnapshot(t, version.After); !reflect.DeepEqual(comparableSnapshot(t, got), want) {
+			t.Fatalf("revert to version %d:\n got %v\nwant %v", version.Version, *got, want)
+		}
+
+		var legs int64
+		db.Model(&models.Trx{}).Where("parent_id = ?", trx.ID).Count(&legs)
+		if wantLegs := len(asSlice(comparableSnapshot(t, version.After)["legs"])); int(legs) != wantLegs {
+			t.Fatalf("revert to version %d: %d legs, want %d", version.Version, legs, wantLegs)
+		}
+	}
+
+	// Последний возврат - к удалению, затем запись восстанавливается из корзины
+	restored := versions[1].After
+	if err := history.Revert(models.HistoryTrx, trx.ID, testUserID, restored); err != nil {
+		t.Fatal(err)
+	}
+	last, err := history.List(models.HistoryTrx, trx.ID, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last[0].Action != models.HistoryRestore {
+		t.Fatalf("action = %s, want %s", last[0].Action, models.HistoryRestore)
+	}
+}
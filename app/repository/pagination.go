@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"finapp/models"
+)
+
+// Применяет к запросу сортировку и курсор страницы.
+// Выбирает на одну запись больше лимита, чтобы понять, есть ли следующая страница
+func paginate(query *gorm.DB, page models.PageRequest) *gorm.DB {
+	op, dir := ">", "ASC"
+	if page.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if page.Cursor != nil {
+		vars := make([]any, 0, 2*len(page.SortVars)+3)
+		vars = append(vars, page.SortVars...)
+		vars = append(vars, page.Cursor.Value)
+		vars = append(vars, page.SortVars...)
+		vars = append(vars, page.Cursor.Value, page.Cursor.ID)
+		query = query.Where(
+			fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?)", page.SortColumn, op),
+			vars...,
+		)
+	}
+
+	return query.
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                fmt.Sprintf("%s %s, id %s", page.SortColumn, dir, dir),
+			Vars:               page.SortVars,
+			WithoutParentheses: true,
+		}}).
+		Limit(page.Limit + 1)
+}
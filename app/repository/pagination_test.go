@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"finapp/models"
+)
+
+func TestPaginate(t *testing.T) {
+	db := newTestDatabase(t)
+	// Одинаковые суммы: порядок внутри них задаёт id
+	for i, amount := range []string{"10", "20", "10", "30", "20", "10"} {
+		trx := models.Trx{Title: fmt.Sprintf("t%d", i+1), Amount: decimal.RequireFromString(amount), UserID: testUserID}
+		if err := db.Create(&trx).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		column string
+		desc   bool
+		limit  int
+		want   [][]uint
+	}{
+		{"amount desc", "amount", true, 2, [][]uint{{4, 5}, {2, 6}, {3, 1}}},
+		{"amount asc", "amount", false, 4, [][]uint{{1, 3, 6, 2}, {5, 4}}},
+		{"title asc single page", "title", false, 10, [][]uint{{1, 2, 3, 4, 5, 6}}},
+		{"id ties by one", "amount", false, 1, [][]uint{{1}, {3}, {6}, {2}, {5}, {4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := models.PageRequest{Limit: tt.limit, Desc: tt.desc, SortColumn: tt.column}
+			for i, want := range tt.want {
+				var trxs []models.Trx
+				if err := paginate(db.Model(&models.Trx{}), page).Find(&trxs).Error; err != nil {
+					t.Fatal(err)
+				}
+				if len(trxs) > tt.limit {
+					trxs = trxs[:tt.limit]
+					if i == len(tt.want)-1 {
+						t.Fatalf("page %d: unexpected next page", i)
+					}
+				} else if i != len(tt.want)-1 {
+					t.Fatalf("page %d: next page is missing", i)
+				}
+
+				got := make([]uint, 0, len(trxs))
+				for _, trx := range trxs {
+					got = append(got, trx.ID)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Fatalf("page %d = %v, want %v", i, got, want)
+				}
+
+				last := trxs[len(trxs)-1]
+				var value any = last.Amount
+				if tt.column == "title" {
+					value = last.Title
+				}
+				page.Cursor = &models.PageCursor{Value: value, ID: last.ID}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+type PayeeRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewPayeeRepository(logger lib.Logger, db lib.Database) PayeeRepository {
+	return PayeeRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r PayeeRepository) WithTrx(trxHandle *gorm.DB) PayeeRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func (r PayeeRepository) Create(payee *models.Payee) error {
+	return r.Database.Create(payee).Error
+}
+
+func (r PayeeRepository) List(userID uint) ([]models.Payee, error) {
+	var payees []models.Payee
+	if err := r.Database.Where("user_id = ?", userID).Order("title").Find(&payees).Error; err != nil {
+		return nil, err
+	}
+	return payees, nil
+}
+
+func (r PayeeRepository) Get(id, userID uint) (models.Payee, error) {
+	var payee models.Payee
+	err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&payee).Error
+	return payee, err
+}
+
+func (r PayeeRepository) Patch(updates map[string]any, id, userID uint) (models.Payee, error) {
+	if len(updates) > 0 {
+		if err := r.Database.Model(&models.Payee{}).
+			Where("id = ? AND user_id = ?", id, userID).
+			Updates(updates).Error; err != nil {
+			return models.Payee{}, err
+		}
+	}
+	return r.Get(id, userID)
+}
+
+// Удаляет получателя и отвязывает от него транзакции и правила
+func (r PayeeRepository) Delete(id, userID uint) error {
+	if err := unlinkTrxs(r.Database.DB, userID, "payee_id", id); err != nil {
+		return err
+	}
+	if err := r.Database.Model(&models.Rule{}).
+		Where("user_id = ? AND payee_id = ?", userID, id).
+		Update("payee_id", nil).Error; err != nil {
+		return err
+	}
+	return r.Database.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Payee{}).Error
+}
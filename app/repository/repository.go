@@ -9,4 +9,13 @@ var Module = fx.Options(
 	fx.Provide(NewBudgetRepository),
 	fx.Provide(NewGoalRepository),
 	fx.Provide(NewGeneratorRepository),
+	fx.Provide(NewCategoryRepository),
+	fx.Provide(NewTagRepository),
+	fx.Provide(NewExchangeRateRepository),
+	fx.Provide(NewAttachmentRepository),
+	fx.Provide(NewPayeeRepository),
+	fx.Provide(NewRuleRepository),
+	fx.Provide(NewTrashRepository),
+	fx.Provide(NewHistoryRepository),
+	fx.Provide(NewBudgetMemberRepository),
 )
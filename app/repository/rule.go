@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+type RuleRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewRuleRepository(logger lib.Logger, db lib.Database) RuleRepository {
+	return RuleRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r RuleRepository) WithTrx(trxHandle *gorm.DB) RuleRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func (r RuleRepository) Create(rule *models.Rule) error {
+	return r.Database.Create(rule).Error
+}
+
+// Правила пользователя в порядке применения
+func (r RuleRepository) List(userID uint) ([]models.Rule, error) {
+	var rules []models.Rule
+	if err := r.Database.Preload("Tags").Where("user_id = ?", userID).
+		Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r RuleRepository) Get(id, userID uint) (models.Rule, error) {
+	var rule models.Rule
+	err := r.Database.Preload("Tags").Where("id = ? AND user_id = ?", id, userID).First(&rule).Error
+	return rule, err
+}
+
+// Заменяет правило целиком вместе с тегами
+func (r RuleRepository) Replace(rule *models.Rule) error {
+	if err := r.Database.Model(rule).Select("*").Omit("CreatedAt", "Tags").Updates(rule).Error; err != nil {
+		return err
+	}
+	return r.Database.Model(rule).Association("Tags").Replace(rule.Tags)
+}
+
+func (r RuleRepository) Delete(id, userID uint) error {
+	if err := r.Database.Exec("DELETE FROM rule_tags WHERE rule_id = ?", id).Error; err != nil {
+		return err
+	}
+	return r.Database.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Rule{}).Error
+}
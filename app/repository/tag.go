@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+type TagRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewTagRepository(logger lib.Logger, db lib.Database) TagRepository {
+	return TagRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r TagRepository) WithTrx(trxHandle *gorm.DB) TagRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func (r TagRepository) List(userID uint) ([]models.Tag, error) {
+	var tags []models.Tag
+	if err := r.Database.Where("user_id = ?", userID).Order("title").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r TagRepository) Get(id, userID uint) (models.Tag, error) {
+	var tag models.Tag
+	err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error
+	return tag, err
+}
+
+func (r TagRepository) GetByTitle(title string, userID uint) (models.Tag, error) {
+	var tag models.Tag
+	err := r.Database.Where("title = ? AND user_id = ?", title, userID).First(&tag).Error
+	return tag, err
+}
+
+// Возвращает теги пользователя с указанными названиями, создавая недостающие
+func (r TagRepository) GetOrCreate(titles []string, userID uint) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(titles))
+	for _, title := range titles {
+		tag, err := r.GetByTitle(title, userID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			tag = models.Tag{UserID: userID, Title: title}
+			if err := r.Database.Create(&tag).Error; err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (r TagRepository) Rename(id, userID uint, title string) (models.Tag, error) {
+	if err := r.Database.Model(&models.Tag{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("title", title).Error; err != nil {
+		return models.Tag{}, err
+	}
+	return r.Get(id, userID)
+}
+
+// Переносит все транзакции и правила тега sourceID на тег targetID и удаляет sourceID
+func (r TagRepository) Merge(sourceID, targetID, userID uint) error {
+	if err := r.Database.Exec("INSERT INTO transaction_tags (trx_id, tag_id) "+
+		"SELECT trx_id, ? FROM transaction_tags WHERE tag_id = ? "+
+		"AND trx_id NOT IN (SELECT trx_id FROM transaction_tags WHERE tag_id = ?)",
+		targetID, sourceID, targetID).Error; err != nil {
+		return err
+	}
+	if err := r.Database.Exec("INSERT INTO rule_tags (rule_id, tag_id) "+
+		"SELECT rule_id, ? FROM rule_tags WHERE tag_id = ? "+
+		"AND rule_id NOT IN (SELECT rule_id FROM rule_tags WHERE tag_id = ?)",
+		targetID, sourceID, targetID).Error; err != nil {
+		return err
+	}
+	return r.Delete(sourceID, userID)
+}
+
+func (r TagRepository) Delete(id, userID uint) error {
+	if err := r.Database.Exec("DELETE FROM transaction_tags WHERE tag_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := r.Database.Exec("DELETE FROM rule_tags WHERE tag_id = ?", id).Error; err != nil {
+		return err
+	}
+	return r.Database.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Tag{}).Error
+}
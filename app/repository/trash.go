@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"finapp/lib"
+	"finapp/models"
+)
+
+// Бюджет нельзя удалить окончательно, пока на него ссылаются транзакции или генераторы
+var ErrBudgetInUse = errors.New("budget is used by transactions or generators")
+
+// Корзина: мягко удалённые транзакции, бюджеты, цели и генераторы
+type TrashRepository struct {
+	logger   lib.Logger
+	Database lib.Database
+}
+
+func NewTrashRepository(logger lib.Logger, db lib.Database) TrashRepository {
+	return TrashRepository{
+		logger:   logger,
+		Database: db,
+	}
+}
+
+func (r TrashRepository) WithTrx(trxHandle *gorm.DB) TrashRepository {
+	if trxHandle == nil {
+		r.logger.Error("Transaction Database not found in gin context. ")
+		return r
+	}
+	r.Database.DB = trxHandle
+	return r
+}
+
+func trashModel(entity models.TrashEntity) (any, error) {
+	switch entity {
+	case models.TrashTrx:
+		return &models.Trx{}, nil
+	case models.TrashBudget:
+		return &models.Budget{}, nil
+	case models.TrashGoal:
+		return &models.Goal{}, nil
+	case models.TrashGenerator:
+		return &models.Generator{}, nil
+	}
+	return nil, fmt.Errorf("unknown trash entity: %s", entity)
+}
+
+// Удалённые записи пользователя, сначала недавно удалённые.
+// Части разделённых транзакций лежат в корзине вместе с транзакцией
+func (r TrashRepository) List(userID uint, entity models.TrashEntity) ([]models.TrashRecord, error) {
+	model, err := trashModel(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.Database.Unscoped().Model(model).Select("id, title, deleted_at").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	if entity == models.TrashTrx {
+		query = query.Where("parent_id IS NULL")
+	}
+
+	var records []models.TrashRecord
+	if err := query.Order("deleted_at DESC, id").Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Entity = entity
+	}
+	return records, nil
+}
+
+// Восстанавливает запись, для транзакции - вместе с частями, удалёнными одновременно с ней
+func (r TrashRepository) Restore(entity models.TrashEntity, id, userID uint) error {
+	model, err := trashModel(entity)
+	if err != nil {
+		return err
+	}
+
+	var deletedAt time.Time
+	if err := r.Database.Unscoped().Model(model).Select("deleted_at").
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		Scan(&deletedAt).Error; err != nil {
+		return err
+	}
+	if deletedAt.IsZero() {
+		return gorm.ErrRecordNotFound
+	}
+
+	return trackHistory(r.Database.DB, models.HistoryEntity(entity), id, userID, func() error {
+		if entity == models.TrashTrx {
+			// Части, заменённые при повторном разделении, удалены раньше и не восстанавливаются
+			if err := r.Database.Unscoped().Model(&models.Trx{}).
+				Where("parent_id = ? AND user_id = ? AND deleted_at = ?", id, userID, deletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		return r.Database.Unscoped().Model(model).
+			Where("id = ? AND user_id = ?", id, userID).
+			Update("deleted_at", nil).Error
+	})
+}
+
+// Окончательно удаляет запись из корзины. Возвращает ключи файлов вложений в хранилище
+func (r TrashRepository) Purge(entity models.TrashEntity, id, userID uint) ([]string, error) {
+	purged, keys, err := r.purge(entity, "id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if purged == 0 {
+		if entity == models.TrashBudget {
+			var trashed int64
+			if err := r.Database.Unscoped().Model(&models.Budget{}).
+				Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+				Count(&trashed).Error; err != nil {
+				return nil, err
+			}
+			if trashed > 0 {
+				return nil, ErrBudgetInUse
+			}
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	return keys, nil
+}
+
+// Очищает корзину пользователя
+func (r TrashRepository) PurgeUser(userID uint) (int64, []string, error) {
+	return r.purgeAll("user_id = ?", userID)
+}
+
+// Окончательно удаляет записи всех пользователей, удалённые раньше before
+func (r TrashRepository) PurgeBefore(before time.Time) (int64, []string, error) {
+	return r.purgeAll("deleted_at < ?", before)
+}
+
+func (r TrashRepository) purgeAll(query string, args ...any) (int64, []string, error) {
+	var (
+		total int64
+		keys  []string
+	)
+	for _, entity := range []models.TrashEntity{models.TrashTrx, models.TrashGenerator, models.TrashBudget, models.TrashGoal} {
+		purged, entityKeys, err := r.purge(entity, query, args...)
+		if err != nil {
+			return 0, nil, err
+		}
+		total += purged
+		keys = append(keys, entityKeys...)
+	}
+	return total, keys, nil
+}
+
+func (r TrashRepository) purge(entity models.TrashEntity, query string, args ...any) (int64, []string, error) {
+	model, err := trashModel(entity)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var ids []uint
+	if err := r.Database.Unscoped().Model(model).
+		Where("deleted_at IS NOT NULL").Where(query, args...).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, nil, err
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+
+	var keys []string
+	switch entity {
+	case models.TrashBudget:
+		ids, err = r.purgeBudgets(ids)
+		if err != nil || len(ids) == 0 {
+			return 0, nil, err
+		}
+	case models.TrashTrx:
+		keys, err = r.purgeTrxs(ids)
+		if err != nil {
+			return 0, nil, err
+		}
+	case models.TrashGoal:
+		if err := r.Database.Unscoped().Model(&models.Budget{}).
+			Where("goal_id IN ?", ids).
+			Update("goal_id", nil).Error; err != nil {
+			return 0, nil, err
+		}
+	}
+
+	res := r.Database.Unscoped().Where("id IN ?", ids).Delete(model)
+	return res.RowsAffected, keys, res.Error
+}
+
+// Удаляет связанные с транзакциями части, теги и вложения
+func (r TrashRepository) purgeTrxs(ids []uint) ([]string, error) {
+	var legIDs []uint
+	if err := r.Database.Unscoped().Model(&models.Trx{}).
+		Where("parent_id IN ?", ids).
+		Pluck("id", &legIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(legIDs) > 0 {
+		if err := r.Database.Unscoped().Where("id IN ?", legIDs).Delete(&models.Trx{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	all := append(ids, legIDs...)
+
+	var keys []string
+	if err := r.Database.Unscoped().Model(&models.Attachment{}).
+		Where("trx_id IN ?", all).
+		Pluck("storage_key", &keys).Error; err != nil {
+		return nil, err
+	}
+	if err := r.Database.Unscoped().Where("trx_id IN ?", all).Delete(&models.Attachment{}).Error; err != nil {
+		return nil, err
+	}
+	if err := r.Database.Exec("DELETE FROM transaction_tags WHERE trx_id IN ?", all).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Бюджеты, на которые ссылаются транзакции или генераторы, в том числе удалённые
+func (r TrashRepository) BudgetsInUse(ids []uint) ([]uint, error) {
+	var inUse []uint
+	for _, model := range []any{&models.Trx{}, &models.Generator{}} {
+		for _, column := range []string{"budget_from", "budget_to"} {
+			var used []uint
+			if err := r.Database.Unscoped().Model(model).
+				Where(column+" IN ?", ids).Distinct().
+				Pluck(column, &used).Error; err != nil {
+				return nil, err
+			}
+			inUse = append(inUse, used...)
+		}
+	}
+	return inUse, nil
+}
+
+// Готовит бюджеты к удалению: бюджеты с транзакциями и генераторами пропускаются,
+// у остальных удаляются участники, снимки остатков и отметки импорта, а ссылки
+// дочерних бюджетов, получателей и правил сбрасываются. Возвращает удаляемые бюджеты
+func (r TrashRepository) purgeBudgets(ids []uint) ([]uint, error) {
+	inUse, err := r.BudgetsInUse(ids)
+	if err != nil {
+		return nil, err
+	}
+	ids = slices.DeleteFunc(ids, func(id uint) bool {
+		return slices.Contains(inUse, id)
+	})
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	for _, model := range []any{&models.BudgetMember{}, &models.BudgetBalance{}, &models.ImportedEntry{}} {
+		if err := r.Database.Unscoped().Where("budget_id IN ?", ids).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := r.Database.Unscoped().Model(&models.Budget{}).
+		Where("parent_id IN ?", ids).
+		Update("parent_id", nil).Error; err != nil {
+		return nil, err
+	}
+	for _, model := range []any{&models.Payee{}, &models.Rule{}} {
+		if err := r.Database.Unscoped().Model(model).
+			Where("budget_id IN ?", ids).
+			Update("budget_id", nil).Error; err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
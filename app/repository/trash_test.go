@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+func TestTrashPurgeBudget(t *testing.T) {
+	db := newTestDatabase(t)
+	trash := TrashRepository{Database: db}
+
+	// 1 - родитель 2, 3 - бюджет транзакции
+	budgets := []models.Budget{{UserID: testUserID}, {UserID: testUserID, ParentID: nullID(1)}, {UserID: testUserID}}
+	if err := db.Create(&budgets).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []any{
+		&models.BudgetMember{BudgetID: 1, UserID: testUserID, Role: models.BudgetRoleOwner},
+		&models.BudgetBalance{BudgetID: 1, Month: testMonth(-1, 1), Balance: decimal.RequireFromString("10")},
+		&models.ImportedEntry{UserID: testUserID, BudgetID: 1, ExternalID: "A1", TrxID: 1},
+		&models.Payee{UserID: testUserID, Title: "Shop", BudgetID: nullID(1)},
+		&models.Rule{UserID: testUserID, Pattern: "shop", BudgetID: nullID(1)},
+		&models.Trx{UserID: testUserID, Amount: decimal.RequireFromString("5"), BudgetFrom: nullID(3), Type: models.TrxTypeExpense},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete(&models.Budget{}, []uint{1, 3}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := trash.Purge(models.TrashBudget, 3, testUserID); !errors.Is(err, ErrBudgetInUse) {
+		t.Fatalf("purge budget with trx: err = %v, want %v", err, ErrBudgetInUse)
+	}
+	if _, err := trash.Purge(models.TrashBudget, 99, testUserID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("purge missing budget: err = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	if _, err := trash.Purge(models.TrashBudget, 1, testUserID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, model := range []any{&models.BudgetMember{}, &models.BudgetBalance{}, &models.ImportedEntry{}} {
+		var count int64
+		if err := db.Model(model).Where("budget_id = ?", 1).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("%T: %d rows of purged budget left", model, count)
+		}
+	}
+	for _, model := range []any{&models.Payee{}, &models.Rule{}} {
+		var count int64
+		if err := db.Model(model).Where("budget_id IS NOT NULL").Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("%T: budget reference is not cleared", model)
+		}
+	}
+	var child models.Budget
+	if err := db.First(&child, 2).Error; err != nil {
+		t.Fatal(err)
+	}
+	if child.ParentID != nil && child.ParentID.Valid {
+		t.Fatalf("child budget still points to purged parent %d", child.ParentID.Int64)
+	}
+
+	// Очистка корзины пропускает бюджет с транзакциями, пока они не удалены окончательно
+	purged, _, err := trash.PurgeUser(testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 0 {
+		t.Fatalf("purged %d records, want 0", purged)
+	}
+	if err := db.Where("budget_from = ?", 3).Delete(&models.Trx{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	purged, _, err = trash.PurgeUser(testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Fatalf("purged %d records, want trx and budget", purged)
+	}
+}
@@ -1,14 +1,22 @@
 package repository
 
 import (
-	"github.com/shopspring/decimal"
-	"gorm.io/gorm"
+	"database/sql"
+	"strings"
 	"time"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
 	"finapp/lib"
 	"finapp/models"
 )
 
+// Поисковый вектор транзакции, по нему же построен GIN индекс
+const trxSearchVector = "to_tsvector('russian', coalesce(title, '') || ' ' || coalesce(note, ''))"
+
+var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
 type TrxRepository struct {
 	logger   lib.Logger
 	Database lib.Database
@@ -34,12 +42,28 @@ func (r TrxRepository) WithTrx(trxHandle *gorm.DB) TrxRepository {
 }
 
 func (r TrxRepository) Create(model *models.Trx) error {
-	return r.Database.Create(&model).Error
+	for i := range model.Legs {
+		legType, err := model.Legs[i].DeriveType()
+		if err != nil {
+			return err
+		}
+		model.Legs[i].Type = legType
+	}
+	trxType, err := model.DeriveType()
+	if err != nil {
+		return err
+	}
+	model.Type = trxType
+	if err := r.Database.Create(&model).Error; err != nil {
+		return err
+	}
+	return recordHistory(r.Database.DB, models.HistoryTrx, model.ID, model.UserID, nil)
 }
 
-func (r TrxRepository) Get(id uint, UserID uint) (models.Trx, error) {
+// Транзакция, доступная пользователю с одной из ролей в общих бюджетах, без ролей - с любой
+func (r TrxRepository) Get(id uint, UserID uint, roles ...models.BudgetRole) (models.Trx, error) {
 	var trx models.Trx
-	err := r.Database.Where("user_id = ? AND id = ?", UserID, id).First(&trx).Error
+	err := trxAccess(r.Database.Preload("Tags").Preload("Legs"), UserID, roles...).Where("id = ?", id).First(&trx).Error
 	if err != nil {
 		return models.Trx{}, err
 	}
@@ -47,28 +71,120 @@ func (r TrxRepository) Get(id uint, UserID uint) (models.Trx, error) {
 	return trx, nil
 }
 
-func (r TrxRepository) List(userID uint, dateFrom, dateTo time.Time, minAmount, maxAmount decimal.Decimal) ([]models.Trx, error) {
+func (r TrxRepository) List(userID uint, filter models.TrxFilter, page models.PageRequest) ([]models.Trx, error) {
 	var trxs []models.Trx
-	query := r.Database.Where("user_id = ?", userID)
-	if !dateFrom.Equal(time.Time{}) {
-		query = query.Where("date >= ?", dateFrom)
+	// Части разделённых транзакций возвращаются вместе с родительской
+	query := trxAccess(r.Database.Preload("Tags").Preload("Legs"), userID).
+		Where("parent_id IS NULL")
+	if filter.Query != "" {
+		match, rank := r.searchExprs(filter.Query)
+		query = query.Where(match.SQL, match.Vars...).
+			Select("*, "+rank.SQL+" AS search_rank", rank.Vars...)
+		if page.Sort == models.SortRelevance {
+			page.SortColumn, page.SortVars = rank.SQL, rank.Vars
+		}
 	}
-	if !dateTo.Equal(time.Time{}) {
-		query = query.Where("date <= ?", dateTo)
+	if !filter.DateFrom.Equal(time.Time{}) {
+		query = query.Where("date >= ?", filter.DateFrom)
 	}
-	if !maxAmount.IsZero() {
-		query = query.Where("amount <= ?", maxAmount)
+	if !filter.DateTo.Equal(time.Time{}) {
+		query = query.Where("date <= ?", filter.DateTo)
 	}
-	if !minAmount.IsZero() {
-		query = query.Where("amount >= ?", minAmount)
+	if !filter.MaxAmount.IsZero() {
+		query = query.Where("amount <= ?", filter.MaxAmount)
 	}
-	err := query.Find(&trxs).Error
+	if !filter.MinAmount.IsZero() {
+		query = query.Where("amount >= ?", filter.MinAmount)
+	}
+	switch {
+	case filter.NoCategory && len(filter.CategoryIDs) > 0:
+		query = query.Where("category_id IN ? OR category_id IS NULL", filter.CategoryIDs)
+	case filter.NoCategory:
+		query = query.Where("category_id IS NULL")
+	case len(filter.CategoryIDs) > 0:
+		query = query.Where("category_id IN ?", filter.CategoryIDs)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := r.Database.Table("transaction_tags").
+			Select("transaction_tags.trx_id").
+			Joins("JOIN tags ON tags.id = transaction_tags.tag_id").
+			Where("tags.user_id = ? AND tags.deleted_at IS NULL", userID).
+			Where("tags.title IN ?", filter.Tags)
+		if filter.TagsMatch == models.TagsMatchAll {
+			tagged = tagged.Group("transaction_tags.trx_id").
+				Having("COUNT(DISTINCT tags.id) = ?", len(filter.Tags))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+	err := paginate(query, page).Find(&trxs).Error
 	return trxs, err
 }
 
+// Обходит транзакции по фильтру в порядке даты пачками,
+// не загружая всю выборку в память
+func (r TrxRepository) Each(userID uint, filter models.TrxFilter, fn func(trx models.Trx) error) error {
+	page := models.PageRequest{
+		Limit:      500,
+		Sort:       "date",
+		SortColumn: "date",
+	}
+	for {
+		trxs, err := r.List(userID, filter, page)
+		if err != nil {
+			return err
+		}
+
+		hasMore := len(trxs) > page.Limit
+		if hasMore {
+			trxs = trxs[:page.Limit]
+		}
+		for _, trx := range trxs {
+			if err := fn(trx); err != nil {
+				return err
+			}
+		}
+		if !hasMore {
+			return nil
+		}
+
+		last := trxs[len(trxs)-1]
+		page.Cursor = &models.PageCursor{Value: last.Date, ID: last.ID}
+	}
+}
+
+// Условие поиска и выражение ранга результата.
+// В Postgres используется полнотекстовый поиск, в остальных СУБД - LIKE
+func (r TrxRepository) searchExprs(q string) (match, rank clause.Expr) {
+	if r.Database.Dialector.Name() == "postgres" {
+		query := "websearch_to_tsquery('russian', ?)"
+		match = clause.Expr{SQL: trxSearchVector + " @@ " + query, Vars: []any{q}}
+		rank = clause.Expr{SQL: "ts_rank(" + trxSearchVector + ", " + query + ")", Vars: []any{q}}
+		return match, rank
+	}
+
+	// Совпадение в названии важнее совпадения в заметке
+	pattern := "%" + likeEscaper.Replace(q) + "%"
+	match = clause.Expr{
+		SQL:  `(title LIKE ? ESCAPE '\' OR note LIKE ? ESCAPE '\')`,
+		Vars: []any{pattern, pattern},
+	}
+	rank = clause.Expr{
+		SQL: `(CASE WHEN title LIKE ? ESCAPE '\' THEN 2 ELSE 0 END + ` +
+			`CASE WHEN note LIKE ? ESCAPE '\' THEN 1 ELSE 0 END)`,
+		Vars: []any{pattern, pattern},
+	}
+	return match, rank
+}
+
 func (r TrxRepository) ListFromBudget(budgetID, userID uint, dateFrom time.Time, dateTo time.Time) ([]models.Trx, error) {
 	var trxs []models.Trx
-	query := r.Database.Where("user_id = ?", userID).
+	query := trxAccess(r.Database.DB, userID).
 		Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).
 		Where("date > ?", dateFrom)
 	if !dateTo.IsZero() {
@@ -78,20 +194,264 @@ func (r TrxRepository) ListFromBudget(budgetID, userID uint, dateFrom time.Time,
 	return trxs, err
 }
 
-func (r TrxRepository) Patch(trx models.Trx, id, userID uint) (models.Trx, error) {
-	var trxResponse models.Trx
-	if err := r.Database.Model(&trxResponse).Where("id = ? AND user_id = ?", id, userID).
-		Updates(&trx).Error; err != nil {
+// Обновляет колонки транзакции по карте, в том числе нулевыми значениями и NULL.
+// Части разделённой транзакции следуют за её датой
+func (r TrxRepository) Patch(updates map[string]any, id, userID uint) (models.Trx, error) {
+	if err := r.track(id, userID, func() error {
+		if len(updates) > 0 {
+			if err := trxAccess(r.Database.Model(&models.Trx{}), userID, models.BudgetWriteRoles...).
+				Where("id = ?", id).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		// Дата и статус частей следуют за разделённой транзакцией
+		for _, column := range []string{"date", "status"} {
+			value, ok := updates[column]
+			if !ok {
+				continue
+			}
+			if err := r.Database.Model(&models.Trx{}).Where("parent_id = ?", id).
+				Update(column, value).Error; err != nil {
+				return err
+			}
+		}
+		_, from := updates["budget_from"]
+		_, to := updates["budget_to"]
+		if from || to {
+			return r.updateType(id, userID)
+		}
+		return nil
+	}); err != nil {
 		return models.Trx{}, err
 	}
 
-	if err := r.Database.Where("id = ? AND user_id = ?", id, userID).First(&trxResponse).Error; err != nil {
+	var trxResponse models.Trx
+	if err := trxAccess(r.Database.Preload("Tags").Preload("Legs"), userID).Where("id = ?", id).
+		First(&trxResponse).Error; err != nil {
 		return models.Trx{}, err
 	}
 
 	return trxResponse, nil
 }
 
+// Заменяет теги транзакции
+func (r TrxRepository) ReplaceTags(trx *models.Trx, tags []models.Tag) error {
+	return r.track(trx.ID, trx.UserID, func() error {
+		return r.Database.Model(trx).Association("Tags").Replace(tags)
+	})
+}
+
+// Заменяет части разделённой транзакции. Бюджеты самой транзакции
+// сбрасываются, т.к. изменения бюджетов учитываются по частям
+func (r TrxRepository) ReplaceLegs(trx *models.Trx, legs []models.Trx) error {
+	return r.track(trx.ID, trx.UserID, func() error {
+		return r.replaceLegs(trx, legs)
+	})
+}
+
+func (r TrxRepository) replaceLegs(trx *models.Trx, legs []models.Trx) error {
+	if err := r.Database.Where("user_id = ? AND parent_id = ?", trx.UserID, trx.ID).
+		Delete(&models.Trx{}).Error; err != nil {
+		return err
+	}
+
+	for i := range legs {
+		legType, err := legs[i].DeriveType()
+		if err != nil {
+			return err
+		}
+		legs[i].ParentID = &trx.ID
+		legs[i].Type = legType
+	}
+	trxType, err := models.Trx{IsSplit: true, Legs: legs}.DeriveType()
+	if err != nil {
+		return err
+	}
+	if err := r.Database.Model(trx).Select("IsSplit", "BudgetFrom", "BudgetTo", "DestAmount", "DestCurrency", "Type").
+		Updates(models.Trx{
+			IsSplit:    true,
+			BudgetFrom: &sql.NullInt64{},
+			BudgetTo:   &sql.NullInt64{},
+			Type:       trxType,
+		}).Error; err != nil {
+		return err
+	}
+
+	if err := r.Database.Create(&legs).Error; err != nil {
+		return err
+	}
+	trx.Legs = legs
+	return nil
+}
+
+// Удаляет транзакцию вместе с её частями
 func (r TrxRepository) Delete(id uint, userID uint) error {
-	return r.Database.Where("user_id = ?", userID).Delete(&models.Trx{}, id).Error
+	return r.track(id, userID, func() error {
+		var trx models.Trx
+		if err := trxAccess(r.Database.DB, userID, models.BudgetWriteRoles...).Where("id = ?", id).
+			First(&trx).Error; err != nil {
+			return err
+		}
+		return r.Database.Where("user_id = ? AND (id = ? OR parent_id = ?)", trx.UserID, id, id).
+			Delete(&models.Trx{}).Error
+	})
+}
+
+// Пересчитывает вид транзакции после смены бюджетов,
+// для части - также вид разделённой транзакции
+func (r TrxRepository) updateType(id, userID uint) error {
+	var trx models.Trx
+	if err := trxAccess(r.Database.Preload("Legs"), userID).Where("id = ?", id).First(&trx).Error; err != nil {
+		return err
+	}
+	trxType, err := trx.DeriveType()
+	if err != nil {
+		return err
+	}
+	if err := r.Database.Model(&trx).Update("type", trxType).Error; err != nil {
+		return err
+	}
+	if trx.ParentID != nil {
+		return r.updateType(*trx.ParentID, userID)
+	}
+	return nil
+}
+
+// Изменение транзакции с записью в историю. Части
+// разделённой транзакции входят в её версию
+func (r TrxRepository) track(id, userID uint, change func() error) error {
+	return trackHistory(r.Database.DB, models.HistoryTrx, id, userID, change)
+}
+
+// Отвязывает транзакции пользователя от категории или получателя (column),
+// каждая изменённая транзакция получает версию в истории. Версия части
+// пишется в историю разделённой транзакции
+func unlinkTrxs(db *gorm.DB, userID uint, column string, id uint) error {
+	linked := func() *gorm.DB {
+		return db.Model(&models.Trx{}).Where("user_id = ? AND "+column+" = ?", userID, id)
+	}
+
+	var ids []uint
+	if err := linked().Distinct().Pluck("COALESCE(parent_id, id)", &ids).Error; err != nil {
+		return err
+	}
+	for _, trxID := range ids {
+		if err := trackHistory(db, models.HistoryTrx, trxID, userID, func() error {
+			return linked().Where("id = ? OR parent_id = ?", trxID, trxID).Update(column, nil).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Отмечает сверенными прошедшие по счёту транзакции бюджета до даты включительно
+func (r TrxRepository) Reconcile(budgetID, userID uint, date time.Time) (int64, error) {
+	cleared := func() *gorm.DB {
+		return trxAccess(r.Database.Model(&models.Trx{}), userID, models.BudgetWriteRoles...).
+			Where("date <= ? AND status = ?", date, models.TrxStatusCleared).
+			Where("budget_from = ? OR budget_to = ?", budgetID, budgetID).
+			Where("is_split = ?", false)
+	}
+
+	// Версия части пишется в историю разделённой транзакции
+	var ids []uint
+	if err := cleared().Distinct().Pluck("COALESCE(parent_id, id)", &ids).Error; err != nil {
+		return 0, err
+	}
+	before := make(map[uint]*string, len(ids))
+	for _, id := range ids {
+		state, err := snapshot(r.Database.DB, models.HistoryTrx, id)
+		if err != nil {
+			return 0, err
+		}
+		before[id] = state
+	}
+
+	res := cleared().Update("status", models.TrxStatusReconciled)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	// Разделённая транзакция сверена, когда сверены все её части
+	unreconciled := r.Database.Model(&models.Trx{}).Select("parent_id").
+		Where("parent_id IS NOT NULL AND status <> ?", models.TrxStatusReconciled)
+	if err := trxAccess(r.Database.Model(&models.Trx{}), userID, models.BudgetWriteRoles...).
+		Where("is_split = ? AND status <> ?", true, models.TrxStatusReconciled).
+		Where("id NOT IN (?)", unreconciled).
+		Update("status", models.TrxStatusReconciled).Error; err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := recordHistory(r.Database.DB, models.HistoryTrx, id, userID, before[id]); err != nil {
+			return 0, err
+		}
+	}
+	return res.RowsAffected, nil
+}
+
+// Проверяет, импортировалась ли уже запись выписки в бюджет
+func (r TrxRepository) IsImported(userID, budgetID uint, externalID string) (bool, error) {
+	var count int64
+	err := r.Database.Unscoped().Model(&models.ImportedEntry{}).
+		Where("user_id = ? AND budget_id = ? AND external_id = ?", userID, budgetID, externalID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r TrxRepository) MarkImported(entry *models.ImportedEntry) error {
+	return r.Database.Create(entry).Error
+}
+
+// Переносит отметки импорта на другую транзакцию
+func (r TrxRepository) MoveImported(fromID, toID, userID uint) error {
+	return r.Database.Model(&models.ImportedEntry{}).
+		Where("trx_id = ? AND user_id = ?", fromID, userID).
+		Update("trx_id", toID).Error
+}
+
+// Транзакции периода в порядке даты для поиска дубликатов
+func (r TrxRepository) ListForDuplicates(userID uint, dateFrom, dateTo time.Time) ([]models.Trx, error) {
+	var trxs []models.Trx
+	query := r.Database.Preload("Tags").Preload("Legs").
+		Where("user_id = ? AND parent_id IS NULL", userID)
+	if !dateFrom.IsZero() {
+		query = query.Where("date >= ?", dateFrom)
+	}
+	if !dateTo.IsZero() {
+		query = query.Where("date <= ?", dateTo)
+	}
+	err := query.Order("date, id").Find(&trxs).Error
+	return trxs, err
+}
+
+// Транзакции с той же суммой и бюджетами, что у trx, в интервале дат
+func (r TrxRepository) ListSimilar(trx models.Trx, dateFrom, dateTo time.Time) ([]models.Trx, error) {
+	var trxs []models.Trx
+	query := r.Database.Preload("Tags").Preload("Legs").
+		Where("user_id = ? AND parent_id IS NULL AND amount = ?", trx.UserID, trx.Amount).
+		Where("date >= ? AND date <= ?", dateFrom, dateTo)
+	for column, id := range map[string]*sql.NullInt64{"budget_from": trx.BudgetFrom, "budget_to": trx.BudgetTo} {
+		if id != nil && id.Valid {
+			query = query.Where(column+" = ?", id.Int64)
+		} else {
+			query = query.Where(column + " IS NULL")
+		}
+	}
+	if trx.ID != 0 {
+		query = query.Where("id <> ?", trx.ID)
+	}
+	err := query.Order("date, id").Find(&trxs).Error
+	return trxs, err
+}
+
+func (r TrxRepository) DismissDuplicate(dismissal *models.DuplicateDismissal) error {
+	return r.Database.Clauses(clause.OnConflict{DoNothing: true}).Create(dismissal).Error
+}
+
+func (r TrxRepository) ListDismissedDuplicates(userID uint) ([]models.DuplicateDismissal, error) {
+	var dismissals []models.DuplicateDismissal
+	err := r.Database.Where("user_id = ?", userID).Find(&dismissals).Error
+	return dismissals, err
 }
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/storage"
+	"finapp/models"
+	"finapp/repository"
+)
+
+// Запас на заголовки multipart формы сверх размера файла
+const attachmentFormOverhead = 64 << 10
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	ErrAttachmentType     = errors.New("attachment type is not allowed")
+)
+
+type AttachmentService struct {
+	logger        lib.Logger
+	repository    repository.AttachmentRepository
+	trxRepository repository.TrxRepository
+	storage       storage.Storage
+	maxSize       int64
+	mimeTypes     map[string]bool
+}
+
+func NewAttachmentService(
+	logger lib.Logger,
+	env lib.Env,
+	repository repository.AttachmentRepository,
+	trxRepository repository.TrxRepository,
+	storage storage.Storage,
+) domains.AttachmentService {
+	mimeTypes := make(map[string]bool)
+	for _, mimeType := range strings.Fields(env.AttachmentMIMETypes) {
+		mimeTypes[mimeType] = true
+	}
+
+	return AttachmentService{
+		logger:        logger,
+		repository:    repository,
+		trxRepository: trxRepository,
+		storage:       storage,
+		maxSize:       env.AttachmentMaxSize,
+		mimeTypes:     mimeTypes,
+	}
+}
+
+func (s AttachmentService) WithTrx(trxHandle *gorm.DB) domains.AttachmentService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	s.trxRepository = s.trxRepository.WithTrx(trxHandle)
+	return s
+}
+
+func (s AttachmentService) List(c *gin.Context, userID uint) ([]models.AttachmentResponse, error) {
+	trxID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.trxRepository.Get(uint(trxID), userID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repository.ListOfTrx(uint(trxID), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.AttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		resp = append(resp, newAttachmentResponse(attachment))
+	}
+	return resp, nil
+}
+
+// Загрузка файла из поля file multipart формы. Тип файла определяется
+// по содержимому, а не по заголовку клиента
+func (s AttachmentService) Upload(c *gin.Context, userID uint) (models.AttachmentResponse, error) {
+	trxID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.AttachmentResponse{}, err
+	}
+	if _, err := editableTrx(s.trxRepository, uint(trxID), userID); err != nil {
+		return models.AttachmentResponse{}, err
+	}
+
+	// Тело запроса ограничивается до разбора формы, чтобы большой файл
+	// не оказался целиком в памяти или во временном файле
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.maxSize+attachmentFormOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return models.AttachmentResponse{}, fmt.Errorf("%w: max size is %d bytes", ErrAttachmentTooLarge, s.maxSize)
+		}
+		return models.AttachmentResponse{}, err
+	}
+	if fileHeader.Size > s.maxSize {
+		return models.AttachmentResponse{}, fmt.Errorf("%w: max size is %d bytes", ErrAttachmentTooLarge, s.maxSize)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return models.AttachmentResponse{}, err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return models.AttachmentResponse{}, err
+	}
+	head = head[:n]
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil || !s.mimeTypes[contentType] {
+		return models.AttachmentResponse{}, fmt.Errorf("%w: %s", ErrAttachmentType, contentType)
+	}
+
+	key, err := newStorageKey(userID, uint(trxID))
+	if err != nil {
+		return models.AttachmentResponse{}, err
+	}
+	if err := s.storage.Save(key, io.MultiReader(bytes.NewReader(head), file)); err != nil {
+		return models.AttachmentResponse{}, err
+	}
+
+	attachment := models.Attachment{
+		UserID:      userID,
+		TrxID:       uint(trxID),
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		StorageKey:  key,
+	}
+	if err := s.repository.Create(&attachment); err != nil {
+		if err := s.storage.Delete(key); err != nil {
+			s.logger.Error("failed to delete attachment file: ", err)
+		}
+		return models.AttachmentResponse{}, err
+	}
+
+	return newAttachmentResponse(attachment), nil
+}
+
+func (s AttachmentService) Download(c *gin.Context, userID uint) (models.AttachmentResponse, io.ReadCloser, error) {
+	attachment, err := s.get(c, userID)
+	if err != nil {
+		return models.AttachmentResponse{}, nil, err
+	}
+
+	file, err := s.storage.Open(attachment.StorageKey)
+	if err != nil {
+		return models.AttachmentResponse{}, nil, err
+	}
+	return newAttachmentResponse(attachment), file, nil
+}
+
+func (s AttachmentService) Delete(c *gin.Context, userID uint) error {
+	attachment, err := s.get(c, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := editableTrx(s.trxRepository, attachment.TrxID, userID); err != nil {
+		return err
+	}
+
+	// Вложение общей транзакции может удалить любой редактор бюджета
+	if err := s.repository.Delete(attachment.ID, attachment.UserID); err != nil {
+		return err
+	}
+
+	// Файл удаляется после фиксации транзакции: при откате вложение
+	// восстанавливается вместе с файлом. Оставшийся файл работе не мешает
+	lib.AfterCommit(c, func() {
+		if err := s.storage.Delete(attachment.StorageKey); err != nil {
+			s.logger.Error("failed to delete attachment file: ", err)
+		}
+	})
+	return nil
+}
+
+func (s AttachmentService) get(c *gin.Context, userID uint) (models.Attachment, error) {
+	trxID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	id, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	return s.repository.Get(uint(id), uint(trxID), userID)
+}
+
+// Случайный ключ файла, имя от клиента в путь не попадает
+func newStorageKey(userID, trxID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%d/%s", userID, trxID, hex.EncodeToString(b)), nil
+}
+
+func newAttachmentResponse(attachment models.Attachment) models.AttachmentResponse {
+	return models.AttachmentResponse{
+		ID:          attachment.ID,
+		TrxID:       attachment.TrxID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   attachment.CreatedAt.Format(constants.DateFormat),
+	}
+}
@@ -18,25 +18,38 @@ import (
 )
 
 type BudgetService struct {
-	logger        lib.Logger
-	repository    repository.BudgetRepository
-	trxRepository repository.TrxRepository
+	logger         lib.Logger
+	repository     repository.BudgetRepository
+	trxRepository  repository.TrxRepository
+	goalRepository repository.GoalRepository
+	rateRepository repository.ExchangeRateRepository
+	genRepository  repository.GeneratorRepository
 }
 
 func NewBudgetService(
 	logger lib.Logger,
 	repository repository.BudgetRepository,
 	trxRepository repository.TrxRepository,
+	goalRepository repository.GoalRepository,
+	rateRepository repository.ExchangeRateRepository,
+	genRepository repository.GeneratorRepository,
 ) domains.BudgetService {
 	return BudgetService{
-		logger:        logger,
-		repository:    repository,
-		trxRepository: trxRepository,
+		logger:         logger,
+		repository:     repository,
+		trxRepository:  trxRepository,
+		goalRepository: goalRepository,
+		rateRepository: rateRepository,
+		genRepository:  genRepository,
 	}
 }
 
 func (s BudgetService) WithTrx(trxHandle *gorm.DB) domains.BudgetService {
 	s.repository = s.repository.WithTrx(trxHandle)
+	s.trxRepository = s.trxRepository.WithTrx(trxHandle)
+	s.goalRepository = s.goalRepository.WithTrx(trxHandle)
+	s.rateRepository = s.rateRepository.WithTrx(trxHandle)
+	s.genRepository = s.genRepository.WithTrx(trxHandle)
 	return s
 }
 
@@ -75,38 +88,58 @@ func (s BudgetService) Get(c *gin.Context, userID uint) (models.BudgetGetRespons
 		return models.BudgetGetResponse{}, errors.New("date_from time goes after date_to")
 	}
 
+	limitPeriods, err := parseLimitPeriods(c)
+	if err != nil {
+		return models.BudgetGetResponse{}, err
+	}
+
 	budget, err := s.repository.Get(uint(id), userID)
 	if err != nil {
 		return models.BudgetGetResponse{}, err
 	}
+	role, err := s.repository.Role(budget.ID, userID)
+	if err != nil {
+		return models.BudgetGetResponse{}, err
+	}
 
-	startAmount, err := s.repository.GetBudgetAmount(uint(id), userID, dateFrom)
+	members, err := s.budgetMembers(c, budget, userID)
 	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return models.BudgetGetResponse{}, err
-		}
-		startAmount = decimal.New(0, 0)
+		return models.BudgetGetResponse{}, err
 	}
 
-	changes, err := s.trxRepository.GetBudgetChanges(uint(id), userID, dateFrom, dateTo)
+	currency := budget.Currency
+	if c.Query("currency") != "" {
+		currency = normalizeCurrency(c.Query("currency"))
+	}
+	startAmount, changes, err := s.budgetChanges(userID, members, currency, dateFrom, dateTo)
 	if err != nil {
 		return models.BudgetGetResponse{}, err
 	}
 
+	income, expense := sumIncomeExpense(changes)
 	resp := models.BudgetGetResponse{
-		ID:      budget.ID,
-		Goal:    convertGoalIDToInt(budget.GoalID),
-		Title:   budget.Title,
-		Amounts: make(map[string]float64),
+		ID:         budget.ID,
+		Goal:       models.IDFromNull(budget.GoalID),
+		Parent:     models.IDFromNull(budget.ParentID),
+		ArchivedAt: convertNullTime(budget.ArchivedAt),
+		Role:       role,
+		Title:      budget.Title,
+		Currency:   currency,
+		Amounts:    make(map[string]decimal.Decimal),
+		Income:     income,
+		Expense:    expense,
+	}
+	if resp.Limit, err = s.limitStatus(budget, userID, currentDate(), limitPeriods); err != nil {
+		return models.BudgetGetResponse{}, err
 	}
 
 	var (
-		currAmount float64
+		currAmount decimal.Decimal
 		currDate   time.Time
 	)
 	if !dateFrom.IsZero() || !startAmount.Equal(decimal.Zero) {
-		resp.Amounts[dateFrom.Format(constants.DateFormat)] = startAmount.InexactFloat64()
-		currAmount = startAmount.InexactFloat64()
+		resp.Amounts[dateFrom.Format(constants.DateFormat)] = startAmount
+		currAmount = startAmount
 		currDate = dateFrom
 	}
 
@@ -115,10 +148,10 @@ func (s BudgetService) Get(c *gin.Context, userID uint) (models.BudgetGetRespons
 			for currDate.Before(change.Date) {
 				currDate = currDate.Add(24 * time.Hour)
 				resp.Amounts[currDate.Format(constants.DateFormat)] =
-					resp.Amounts[currDate.Format(constants.DateFormat)] + currAmount
+					resp.Amounts[currDate.Format(constants.DateFormat)].Add(currAmount)
 			}
 		}
-		currAmount = currAmount + change.AmountChange.InexactFloat64()
+		currAmount = currAmount.Add(change.AmountChange)
 		resp.Amounts[currDate.Format(constants.DateFormat)] = currAmount
 		currDate = change.Date
 	}
@@ -133,7 +166,13 @@ func (s BudgetService) Get(c *gin.Context, userID uint) (models.BudgetGetRespons
 	return resp, nil
 }
 
-func (s BudgetService) List(c *gin.Context, userID uint) ([]models.BudgetGetResponse, error) {
+// Поля сортировки списка бюджетов
+var budgetSorts = map[string]sortField{
+	"created_at": {Column: "created_at", Kind: sortKindTime},
+	"title":      {Column: "title", Kind: sortKindString},
+}
+
+func (s BudgetService) List(c *gin.Context, userID uint) (models.PageResponse[models.BudgetGetResponse], error) {
 	var (
 		dateFrom time.Time
 		dateTo   time.Time
@@ -142,7 +181,7 @@ func (s BudgetService) List(c *gin.Context, userID uint) ([]models.BudgetGetResp
 	if dateFromStr := c.Query("date_from"); dateFromStr != "" {
 		dateFromTemp, err := time.Parse(constants.DateFormat, dateFromStr)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.BudgetGetResponse]{}, err
 		}
 		dateFrom = dateFromTemp
 	}
@@ -150,54 +189,91 @@ func (s BudgetService) List(c *gin.Context, userID uint) ([]models.BudgetGetResp
 	if dateToStr := c.Query("date_to"); dateToStr != "" {
 		dateToTemp, err := time.Parse(constants.DateFormat, dateToStr)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.BudgetGetResponse]{}, err
 		}
 		dateTo = dateToTemp
 	}
 
 	if !dateTo.IsZero() && dateTo.Before(dateFrom) {
-		return nil, errors.New("date_from time goes after date_to")
+		return models.PageResponse[models.BudgetGetResponse]{}, errors.New("date_from time goes after date_to")
+	}
+
+	limitPeriods, err := parseLimitPeriods(c)
+	if err != nil {
+		return models.PageResponse[models.BudgetGetResponse]{}, err
+	}
+
+	var archived bool
+	if archivedStr := c.Query("archived"); archivedStr != "" {
+		if archived, err = strconv.ParseBool(archivedStr); err != nil {
+			return models.PageResponse[models.BudgetGetResponse]{}, err
+		}
 	}
 
-	budgets, err := s.repository.List(userID)
+	page, err := parsePageRequest(c, budgetSorts, "created_at")
 	if err != nil {
-		return nil, err
+		return models.PageResponse[models.BudgetGetResponse]{}, err
 	}
 
-	var budgetsAmounts []models.BudgetGetResponse
-	for _, v := range budgets {
-		budget, err := s.repository.Get(v.ID, userID)
+	// Архивные бюджеты показываются только с archived=true
+	budgets, err := s.repository.ListPage(userID, archived, page)
+	if err != nil {
+		return models.PageResponse[models.BudgetGetResponse]{}, err
+	}
+	budgetPage := newPageResponse(budgets, page, func(budget models.Budget) (any, uint) {
+		return budgetSortValue(budget, page.Sort), budget.ID
+	}, func(budget models.Budget) models.Budget {
+		return budget
+	})
+
+	resp := models.PageResponse[models.BudgetGetResponse]{
+		Items:      make([]models.BudgetGetResponse, 0, len(budgetPage.Items)),
+		NextCursor: budgetPage.NextCursor,
+	}
+	for _, budget := range budgetPage.Items {
+		role, err := s.repository.Role(budget.ID, userID)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.BudgetGetResponse]{}, err
 		}
 
-		startAmount, err := s.repository.GetBudgetAmount(v.ID, userID, dateFrom)
+		members, err := s.budgetMembers(c, budget, userID)
 		if err != nil {
-			if !errors.Is(err, sql.ErrNoRows) {
-				return nil, err
-			}
-			startAmount = decimal.New(0, 0)
+			return models.PageResponse[models.BudgetGetResponse]{}, err
 		}
 
-		changes, err := s.trxRepository.GetBudgetChanges(v.ID, userID, dateFrom, dateTo)
+		currency := budget.Currency
+		if c.Query("currency") != "" {
+			currency = normalizeCurrency(c.Query("currency"))
+		}
+		startAmount, changes, err := s.budgetChanges(userID, members, currency, dateFrom, dateTo)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.BudgetGetResponse]{}, err
 		}
 
+		income, expense := sumIncomeExpense(changes)
 		budg := models.BudgetGetResponse{
-			ID:      budget.ID,
-			Goal:    convertGoalIDToInt(budget.GoalID),
-			Title:   budget.Title,
-			Amounts: make(map[string]float64),
+			ID:         budget.ID,
+			Goal:       models.IDFromNull(budget.GoalID),
+			Parent:     models.IDFromNull(budget.ParentID),
+			ArchivedAt: convertNullTime(budget.ArchivedAt),
+			Role:       role,
+			Title:      budget.Title,
+			Currency:   currency,
+			Amounts:    make(map[string]decimal.Decimal),
+			Income:     income,
+			Expense:    expense,
+		}
+		if budg.Limit, err = s.limitStatus(budget, userID, currentDate(), limitPeriods); err != nil {
+			return models.PageResponse[models.BudgetGetResponse]{}, err
 		}
 
 		var (
-			currAmount float64
+			currAmount decimal.Decimal
 			currDate   time.Time
 		)
 		if !dateFrom.IsZero() || !startAmount.Equal(decimal.Zero) {
-			budg.Amounts[dateFrom.Format(constants.DateFormat)] = startAmount.InexactFloat64()
-			currAmount = startAmount.InexactFloat64()
+			budg.Amounts[dateFrom.Format(constants.DateFormat)] = startAmount
+			currAmount = startAmount
 			currDate = dateFrom
 		}
 
@@ -206,10 +282,10 @@ func (s BudgetService) List(c *gin.Context, userID uint) ([]models.BudgetGetResp
 				for currDate.Before(change.Date) {
 					currDate = currDate.Add(24 * time.Hour)
 					budg.Amounts[currDate.Format(constants.DateFormat)] =
-						budg.Amounts[currDate.Format(constants.DateFormat)] + currAmount
+						budg.Amounts[currDate.Format(constants.DateFormat)].Add(currAmount)
 				}
 			}
-			currAmount = currAmount + change.AmountChange.InexactFloat64()
+			currAmount = currAmount.Add(change.AmountChange)
 			budg.Amounts[currDate.Format(constants.DateFormat)] = currAmount
 			currDate = change.Date
 		}
@@ -221,17 +297,38 @@ func (s BudgetService) List(c *gin.Context, userID uint) ([]models.BudgetGetResp
 			}
 		}
 
-		budgetsAmounts = append(budgetsAmounts, budg)
+		resp.Items = append(resp.Items, budg)
 	}
 
-	return budgetsAmounts, err
+	return resp, nil
+}
+
+func budgetSortValue(budget models.Budget, sort string) any {
+	if sort == "title" {
+		return budget.Title
+	}
+	return budget.CreatedAt
 }
 
 func (s BudgetService) Create(request *models.BudgetCreateRequest, userID uint) (models.BudgetCreateResponse, error) {
+	if err := checkGoal(s.goalRepository, userID, request.Goal); err != nil {
+		return models.BudgetCreateResponse{}, err
+	}
+	if err := s.checkParent(userID, 0, request.Parent); err != nil {
+		return models.BudgetCreateResponse{}, err
+	}
+
 	budget := models.Budget{
-		UserID: userID,
-		Title:  request.Title,
-		GoalID: convertGoalIDFromInt(request.Goal),
+		UserID:   userID,
+		Title:    request.Title,
+		GoalID:   models.NullID(request.Goal),
+		ParentID: models.NullID(request.Parent),
+		Currency: normalizeCurrency(request.Currency),
+	}
+	if request.Limit != nil {
+		if err := setBudgetLimit(&budget, request.Limit); err != nil {
+			return models.BudgetCreateResponse{}, err
+		}
 	}
 
 	if err := s.repository.Create(&budget); err != nil {
@@ -239,9 +336,12 @@ func (s BudgetService) Create(request *models.BudgetCreateRequest, userID uint)
 	}
 
 	newBudget := models.BudgetCreateResponse{
-		ID:     budget.ID,
-		Title:  budget.Title,
-		GoadID: convertGoalIDToInt(budget.GoalID),
+		ID:       budget.ID,
+		Title:    budget.Title,
+		GoadID:   models.IDFromNull(budget.GoalID),
+		Currency: budget.Currency,
+		Limit:    convertBudgetLimit(budget),
+		Parent:   models.IDFromNull(budget.ParentID),
 	}
 
 	return newBudget, nil
@@ -257,9 +357,35 @@ func (s BudgetService) Patch(c *gin.Context, budget models.BudgetPatchRequest, u
 		return models.BudgetPatchResponse{}, err
 	}
 
+	if err := checkBudgetRole(s.repository, uint(id), userID, models.BudgetWriteRoles...); err != nil {
+		return models.BudgetPatchResponse{}, err
+	}
+	if err := checkGoal(s.goalRepository, userID, budget.Goal); err != nil {
+		return models.BudgetPatchResponse{}, err
+	}
+
 	updateBudget := models.Budget{
 		Title:  budget.Title,
-		GoalID: convertGoalIDFromInt(budget.Goal),
+		GoalID: models.NullID(budget.Goal),
+	}
+
+	if budget.Parent.Set {
+		if err := s.checkParent(userID, uint(id), budget.Parent.Value); err != nil {
+			return models.BudgetPatchResponse{}, err
+		}
+		if err := s.repository.SetParent(models.NullID(budget.Parent.Value), uint(id), userID); err != nil {
+			return models.BudgetPatchResponse{}, err
+		}
+	}
+
+	if budget.Limit.Set {
+		var limit models.Budget
+		if err := setBudgetLimit(&limit, budget.Limit.Value); err != nil {
+			return models.BudgetPatchResponse{}, err
+		}
+		if err := s.repository.SetLimit(limit, uint(id), userID); err != nil {
+			return models.BudgetPatchResponse{}, err
+		}
 	}
 
 	budgetDB, err := s.repository.Patch(&updateBudget, uint(id), userID)
@@ -268,9 +394,11 @@ func (s BudgetService) Patch(c *gin.Context, budget models.BudgetPatchRequest, u
 	}
 
 	resp := models.BudgetPatchResponse{
-		ID:    budgetDB.ID,
-		Title: budgetDB.Title,
-		Goal:  convertGoalIDToInt(budgetDB.GoalID),
+		ID:     budgetDB.ID,
+		Title:  budgetDB.Title,
+		Goal:   models.IDFromNull(budgetDB.GoalID),
+		Limit:  convertBudgetLimit(budgetDB),
+		Parent: models.IDFromNull(budgetDB.ParentID),
 	}
 
 	return resp, nil
@@ -285,28 +413,123 @@ func (s BudgetService) Delete(c *gin.Context, userID uint) error {
 	if err != nil {
 		return err
 	}
+	if err := checkBudgetRole(s.repository, uint(id), userID, models.BudgetRoleOwner); err != nil {
+		return err
+	}
 
 	return s.repository.Delete(uint(id), userID)
 }
 
-func convertGoalIDToInt(goal *sql.NullInt64) *uint {
-	if goal == nil {
-		return nil
+// Сверка бюджета с выпиской. Остаток бюджета на дату выписки без транзакций
+// в статусе pending должен совпасть с остатком выписки, тогда прошедшие
+// по счёту транзакции до этой даты отмечаются сверенными и блокируются
+func (s BudgetService) Reconcile(c *gin.Context, request models.BudgetReconcileRequest, userID uint) (models.BudgetReconcileResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.BudgetReconcileResponse{}, err
+	}
+	date, err := time.Parse(constants.DateFormat, request.Date)
+	if err != nil {
+		return models.BudgetReconcileResponse{}, err
+	}
+
+	budget, err := s.repository.Get(uint(id), userID)
+	if err != nil {
+		return models.BudgetReconcileResponse{}, err
+	}
+	if err := checkBudgetRole(s.repository, budget.ID, userID, models.BudgetWriteRoles...); err != nil {
+		return models.BudgetReconcileResponse{}, err
+	}
+
+	amount, err := s.repository.GetBudgetAmount(budget.ID, userID, date)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return models.BudgetReconcileResponse{}, err
+		}
+		amount = decimal.Zero
+	}
+	pending, err := s.repository.GetPendingAmount(budget.ID, userID, date)
+	if err != nil {
+		return models.BudgetReconcileResponse{}, err
+	}
+	cleared := amount.Sub(pending)
+	balance := request.Balance
+	difference := balance.Sub(cleared)
+
+	resp := models.BudgetReconcileResponse{
+		Reconciled:       difference.IsZero(),
+		Date:             date.Format(constants.DateFormat),
+		StatementBalance: balance,
+		ClearedBalance:   cleared,
+		Difference:       difference,
 	}
-	var id uint
-	if goal.Valid {
-		id = uint(goal.Int64)
-		return &id
+	if !resp.Reconciled {
+		return resp, nil
 	}
-	return nil
+
+	resp.ReconciledCount, err = s.trxRepository.Reconcile(budget.ID, userID, date)
+	if err != nil {
+		return models.BudgetReconcileResponse{}, err
+	}
+	return resp, nil
 }
 
-func convertGoalIDFromInt(id *uint) *sql.NullInt64 {
-	if id != nil {
-		return &sql.NullInt64{
-			Int64: int64(*id),
-			Valid: true,
+// Пересчитывает начальную сумму и изменения бюджета в валюту to
+// по курсам на дату начала периода и даты изменений
+func (s BudgetService) convertChanges(
+	userID uint,
+	from, to string,
+	dateFrom time.Time,
+	startAmount decimal.Decimal,
+	changes []models.BudgetChanges,
+) (decimal.Decimal, []models.BudgetChanges, error) {
+	if from == to {
+		return startAmount, changes, nil
+	}
+
+	converter := newCurrencyConverter(s.rateRepository, userID, to)
+	startAmount, err := converter.Convert(startAmount, from, dateFrom)
+	if err != nil {
+		return decimal.Decimal{}, nil, err
+	}
+	for i, change := range changes {
+		changes[i].AmountChange, err = converter.Convert(change.AmountChange, from, change.Date)
+		if err != nil {
+			return decimal.Decimal{}, nil, err
 		}
+		changes[i].Income, err = converter.Convert(change.Income, from, change.Date)
+		if err != nil {
+			return decimal.Decimal{}, nil, err
+		}
+		changes[i].Expense, err = converter.Convert(change.Expense, from, change.Date)
+		if err != nil {
+			return decimal.Decimal{}, nil, err
+		}
+	}
+	return startAmount, changes, nil
+}
+
+// Пересчитывает снимки остатков бюджета заново, budgetID 0 - всех бюджетов
+func (s BudgetService) RebuildBalances(budgetID uint) (models.BudgetBalanceRebuildResponse, error) {
+	if budgetID == 0 {
+		return s.repository.RebuildBalances()
+	}
+	return s.repository.RebuildBalances(budgetID)
+}
+
+// Бюджеты с устаревшими снимками остатков, budgetID 0 - среди всех бюджетов
+func (s BudgetService) CheckBalances(budgetID uint) ([]uint, error) {
+	if budgetID == 0 {
+		return s.repository.CheckBalances()
+	}
+	return s.repository.CheckBalances(budgetID)
+}
+
+// Доходы и расходы за период, переводы между бюджетами в них не входят
+func sumIncomeExpense(changes []models.BudgetChanges) (income, expense decimal.Decimal) {
+	for _, change := range changes {
+		income = income.Add(change.Income)
+		expense = expense.Add(change.Expense)
 	}
-	return &sql.NullInt64{}
+	return income, expense
 }
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"finapp/constants"
+	"finapp/models"
+)
+
+var ErrBudgetArchived = errors.New("budget is archived")
+
+// Скрывает бюджет из списка бюджетов, его транзакции остаются в отчётах
+func (s BudgetService) Archive(c *gin.Context, userID uint) (models.BudgetArchiveResponse, error) {
+	budget, err := s.budgetFromParam(c, userID)
+	if err != nil {
+		return models.BudgetArchiveResponse{}, err
+	}
+
+	// Повторная архивация сохраняет прежнюю дату
+	if !isArchived(budget) {
+		budget.ArchivedAt = &sql.NullTime{Time: currentDate(), Valid: true}
+		if err := s.repository.SetArchived(budget.ArchivedAt, budget.ID, userID); err != nil {
+			return models.BudgetArchiveResponse{}, err
+		}
+	}
+
+	return models.BudgetArchiveResponse{
+		ID:         budget.ID,
+		ArchivedAt: convertNullTime(budget.ArchivedAt),
+	}, nil
+}
+
+// Возвращает бюджет из архива. Остановленные при закрытии генераторы не возобновляются
+func (s BudgetService) Unarchive(c *gin.Context, userID uint) (models.BudgetArchiveResponse, error) {
+	budget, err := s.budgetFromParam(c, userID)
+	if err != nil {
+		return models.BudgetArchiveResponse{}, err
+	}
+
+	if err := s.repository.SetArchived(&sql.NullTime{}, budget.ID, userID); err != nil {
+		return models.BudgetArchiveResponse{}, err
+	}
+	return models.BudgetArchiveResponse{ID: budget.ID}, nil
+}
+
+// Закрывает бюджет: переводит его остаток на дату закрытия в другой бюджет,
+// останавливает генераторы бюджета и архивирует его
+func (s BudgetService) Close(c *gin.Context, request models.BudgetCloseRequest, userID uint) (models.BudgetCloseResponse, error) {
+	date := currentDate()
+	if request.Date != "" {
+		var err error
+		date, err = time.Parse(constants.DateFormat, request.Date)
+		if err != nil {
+			return models.BudgetCloseResponse{}, err
+		}
+	}
+
+	budget, err := s.budgetFromParam(c, userID)
+	if err != nil {
+		return models.BudgetCloseResponse{}, err
+	}
+	if isArchived(budget) {
+		return models.BudgetCloseResponse{}, ErrBudgetArchived
+	}
+
+	if request.BudgetTo == budget.ID {
+		return models.BudgetCloseResponse{}, ReferenceError{"BudgetTo": "nefield=ID"}
+	}
+	target, err := s.repository.Get(request.BudgetTo, userID)
+	if err != nil {
+		return models.BudgetCloseResponse{}, checkExists("BudgetTo", err)
+	}
+	if isArchived(target) {
+		return models.BudgetCloseResponse{}, ReferenceError{"BudgetTo": "active"}
+	}
+	if err := checkBudgetRole(s.repository, target.ID, userID, models.BudgetWriteRoles...); err != nil {
+		if errors.Is(err, ErrBudgetForbidden) {
+			return models.BudgetCloseResponse{}, ReferenceError{"BudgetTo": "editable"}
+		}
+		return models.BudgetCloseResponse{}, err
+	}
+
+	balance, err := s.repository.GetBudgetAmount(budget.ID, userID, date)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return models.BudgetCloseResponse{}, err
+		}
+		balance = decimal.Zero
+	}
+
+	resp := models.BudgetCloseResponse{
+		ID:         budget.ID,
+		ArchivedAt: date.Format(constants.DateFormat),
+		Balance:    balance,
+	}
+
+	if !balance.IsZero() {
+		trx, err := s.closingTrx(budget, target, balance, date)
+		if err != nil {
+			return models.BudgetCloseResponse{}, err
+		}
+		if err := s.trxRepository.Create(&trx); err != nil {
+			return models.BudgetCloseResponse{}, err
+		}
+		resp.TrxID = &trx.ID
+	}
+
+	if resp.StoppedGenerators, err = s.genRepository.StopForBudget(budget.ID, userID, date); err != nil {
+		return models.BudgetCloseResponse{}, err
+	}
+
+	if err := s.repository.SetArchived(&sql.NullTime{Time: date, Valid: true}, budget.ID, userID); err != nil {
+		return models.BudgetCloseResponse{}, err
+	}
+	return resp, nil
+}
+
+// Перевод остатка закрываемого бюджета в target. Отрицательный остаток
+// погашается переводом из target. Сумма перевода - в валюте бюджета списания,
+// при разных валютах зачисление пересчитывается по курсу на дату закрытия
+func (s BudgetService) closingTrx(budget, target models.Budget, balance decimal.Decimal, date time.Time) (models.Trx, error) {
+	from, to := budget, target
+	amount := balance
+	if balance.IsNegative() {
+		from, to = target, budget
+		amount = balance.Neg()
+	}
+
+	trx := models.Trx{
+		UserID:     budget.UserID,
+		Title:      fmt.Sprintf("Закрытие бюджета %s", budget.Title),
+		Date:       date,
+		Amount:     amount,
+		BudgetFrom: models.NullID(&from.ID),
+		BudgetTo:   models.NullID(&to.ID),
+		Currency:   from.Currency,
+	}
+	if from.Currency == to.Currency {
+		return trx, nil
+	}
+
+	// Остаток известен в валюте закрываемого бюджета
+	converter := newCurrencyConverter(s.rateRepository, budget.UserID, target.Currency)
+	converted, err := converter.Convert(balance.Abs(), budget.Currency, date)
+	if err != nil {
+		return models.Trx{}, err
+	}
+	converted = converted.Round(2)
+
+	trx.DestCurrency = to.Currency
+	if balance.IsNegative() {
+		trx.Amount = converted
+		trx.DestAmount = &amount
+	} else {
+		trx.DestAmount = &converted
+	}
+	return trx, nil
+}
+
+// Бюджет из пути запроса, которым пользователь владеет
+func (s BudgetService) budgetFromParam(c *gin.Context, userID uint) (models.Budget, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.Budget{}, err
+	}
+	budget, err := s.repository.Get(uint(id), userID)
+	if err != nil {
+		return models.Budget{}, err
+	}
+	if err := checkBudgetRole(s.repository, budget.ID, userID, models.BudgetRoleOwner); err != nil {
+		return models.Budget{}, err
+	}
+	return budget, nil
+}
+
+func isArchived(budget models.Budget) bool {
+	return budget.ArchivedAt != nil && budget.ArchivedAt.Valid
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"finapp/constants"
+	"finapp/models"
+)
+
+// Число прошедших периодов лимита в ответе по умолчанию
+const defaultLimitPeriods = 12
+
+// Записывает лимит из запроса в поля бюджета, nil снимает лимит
+func setBudgetLimit(budget *models.Budget, limit *models.BudgetLimit) error {
+	if limit == nil {
+		budget.LimitAmount = nil
+		budget.LimitPeriod = ""
+		budget.LimitStart = &sql.NullTime{}
+		return nil
+	}
+
+	start, err := time.Parse(constants.DateFormat, limit.StartDate)
+	if err != nil {
+		return err
+	}
+	budget.LimitAmount = &limit.Amount
+	budget.LimitPeriod = limit.Period
+	budget.LimitStart = &sql.NullTime{Time: start, Valid: true}
+	return nil
+}
+
+func convertBudgetLimit(budget models.Budget) *models.BudgetLimit {
+	if budget.LimitAmount == nil || budget.LimitStart == nil || !budget.LimitStart.Valid {
+		return nil
+	}
+	return &models.BudgetLimit{
+		Amount:    *budget.LimitAmount,
+		Period:    budget.LimitPeriod,
+		StartDate: budget.LimitStart.Time.Format(constants.DateFormat),
+	}
+}
+
+// limit_periods - число прошедших периодов лимита в ответе
+func parseLimitPeriods(c *gin.Context) (int, error) {
+	periodsStr := c.Query("limit_periods")
+	if periodsStr == "" {
+		return defaultLimitPeriods, nil
+	}
+	periods, err := strconv.Atoi(periodsStr)
+	if err != nil {
+		return 0, err
+	}
+	if periods < 0 {
+		return 0, errors.New("limit_periods must not be negative")
+	}
+	return periods, nil
+}
+
+// Начало периода лимита с номером n, считая от начала первого периода
+func limitPeriodStart(budget models.Budget, n int) time.Time {
+	switch budget.LimitPeriod {
+	case models.PeriodicityDaily:
+		return budget.LimitStart.Time.AddDate(0, 0, n)
+	case models.PeriodicityYearly:
+		return budget.LimitStart.Time.AddDate(n, 0, 0)
+	}
+	return budget.LimitStart.Time.AddDate(0, n, 0)
+}
+
+// Расходы бюджета с лимитом за текущий и past прошедших периодов на дату today.
+// Темп расходов текущего периода считается по прошедшим дням, включая today
+func (s BudgetService) limitStatus(budget models.Budget, userID uint, today time.Time, past int) (*models.BudgetLimitResponse, error) {
+	limit := convertBudgetLimit(budget)
+	if limit == nil {
+		return nil, nil
+	}
+
+	resp := &models.BudgetLimitResponse{
+		BudgetLimit: *limit,
+		Past:        make([]models.BudgetLimitPeriod, 0),
+	}
+	if today.Before(budget.LimitStart.Time) {
+		return resp, nil
+	}
+
+	current := 0
+	for !limitPeriodStart(budget, current+1).After(today) {
+		current++
+	}
+	first := max(current-past, 0)
+
+	starts := make([]time.Time, 0, current-first+2)
+	for n := first; n <= current+1; n++ {
+		starts = append(starts, limitPeriodStart(budget, n))
+	}
+
+	changes, err := s.trxRepository.GetBudgetChanges(budget.ID, userID,
+		starts[0].AddDate(0, 0, -1), starts[len(starts)-1].AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make([]decimal.Decimal, len(starts)-1)
+	for _, change := range changes {
+		for i := range spent {
+			if !change.Date.Before(starts[i]) && change.Date.Before(starts[i+1]) {
+				spent[i] = spent[i].Add(change.Expense)
+				break
+			}
+		}
+	}
+
+	amount := *budget.LimitAmount
+	for i := len(spent) - 1; i >= 0; i-- {
+		period := models.BudgetLimitPeriod{
+			DateFrom:  starts[i].Format(constants.DateFormat),
+			DateTo:    starts[i+1].AddDate(0, 0, -1).Format(constants.DateFormat),
+			Spent:     spent[i],
+			Remaining: amount.Sub(spent[i]),
+			Over:      spent[i].GreaterThan(amount),
+		}
+		if i == len(spent)-1 {
+			resp.Current = &period
+			continue
+		}
+		resp.Past = append(resp.Past, period)
+	}
+
+	currentSpent := spent[len(spent)-1]
+	days := decimal.NewFromInt(int64(starts[len(starts)-1].Sub(starts[len(starts)-2]).Hours() / 24))
+	elapsed := decimal.NewFromInt(int64(today.Sub(starts[len(starts)-2]).Hours()/24) + 1)
+	projected := currentSpent.Mul(days).Div(elapsed)
+
+	resp.Projected = projected.Round(2)
+	resp.OverLimit = currentSpent.GreaterThan(amount)
+	resp.OnPaceToExceed = !resp.OverLimit && projected.GreaterThan(amount)
+	return resp, nil
+}
+
+// Сегодняшняя дата в том же виде, что и даты транзакций
+func currentDate() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
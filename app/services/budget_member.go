@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+var (
+	ErrBudgetForbidden = errors.New("not enough rights for budget")
+	ErrBudgetCreator   = errors.New("budget creator can't leave budget or change role")
+)
+
+// Участники общих бюджетов: приглашения владельцами и их принятие
+type BudgetMemberService struct {
+	logger           lib.Logger
+	repository       repository.BudgetMemberRepository
+	budgetRepository repository.BudgetRepository
+	userRepository   repository.UserRepository
+}
+
+func NewBudgetMemberService(
+	logger lib.Logger,
+	repository repository.BudgetMemberRepository,
+	budgetRepository repository.BudgetRepository,
+	userRepository repository.UserRepository,
+) domains.BudgetMemberService {
+	return BudgetMemberService{
+		logger:           logger,
+		repository:       repository,
+		budgetRepository: budgetRepository,
+		userRepository:   userRepository,
+	}
+}
+
+func (s BudgetMemberService) WithTrx(trxHandle *gorm.DB) domains.BudgetMemberService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	s.budgetRepository = s.budgetRepository.WithTrx(trxHandle)
+	s.userRepository = s.userRepository.WithTrx(trxHandle)
+	return s
+}
+
+// Участники бюджета и приглашённые в него, видны всем участникам
+func (s BudgetMemberService) List(c *gin.Context, userID uint) ([]models.BudgetMemberResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.budgetRepository.Get(uint(id), userID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repository.List(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	return newBudgetMemberResponses(members), nil
+}
+
+// Непринятые приглашения пользователя
+func (s BudgetMemberService) Invitations(userID uint) ([]models.BudgetMemberResponse, error) {
+	members, err := s.repository.Invitations(userID)
+	if err != nil {
+		return nil, err
+	}
+	return newBudgetMemberResponses(members), nil
+}
+
+// Приглашение пользователя по почте. Приглашение участника меняет его роль
+func (s BudgetMemberService) Invite(c *gin.Context, request models.BudgetInviteRequest, userID uint) (models.BudgetMemberResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+	budget, err := s.budgetRepository.Get(uint(id), userID)
+	if err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+	if err := checkBudgetRole(s.budgetRepository, budget.ID, userID, models.BudgetRoleOwner); err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+
+	user, err := s.userRepository.GetByEmail(&request.Email)
+	if err != nil {
+		return models.BudgetMemberResponse{}, checkExists("Email", err)
+	}
+	if user.ID == budget.UserID {
+		return models.BudgetMemberResponse{}, ErrBudgetCreator
+	}
+
+	member := models.BudgetMember{
+		BudgetID:  budget.ID,
+		UserID:    user.ID,
+		Role:      request.Role,
+		InvitedBy: userID,
+	}
+	if err := s.repository.Invite(&member); err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+	member.User = *user
+	return newBudgetMemberResponse(member), nil
+}
+
+// Принятие приглашения в бюджет
+func (s BudgetMemberService) Accept(c *gin.Context, userID uint) (models.BudgetMemberResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+	if _, err := s.repository.Get(uint(id), userID); err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+
+	if err := s.repository.Accept(uint(id), userID); err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+	member, err := s.repository.Get(uint(id), userID)
+	if err != nil {
+		return models.BudgetMemberResponse{}, err
+	}
+	return newBudgetMemberResponse(member), nil
+}
+
+// Исключение участника владельцем, отказ от приглашения или выход из бюджета.
+// Создатель бюджета остаётся его владельцем
+func (s BudgetMemberService) Remove(c *gin.Context, userID uint) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return err
+	}
+
+	member, err := s.repository.Get(uint(id), uint(memberID))
+	if err != nil {
+		return err
+	}
+	if member.UserID != userID {
+		if err := checkBudgetRole(s.budgetRepository, member.BudgetID, userID, models.BudgetRoleOwner); err != nil {
+			return err
+		}
+	}
+
+	budget, err := s.budgetRepository.Get(member.BudgetID, member.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if budget.UserID == member.UserID {
+		return ErrBudgetCreator
+	}
+	return s.repository.Delete(member.BudgetID, member.UserID)
+}
+
+// Проверяет, что роль пользователя в бюджете - одна из roles
+func checkBudgetRole(budgets repository.BudgetRepository, budgetID, userID uint, roles ...models.BudgetRole) error {
+	role, err := budgets.Role(budgetID, userID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(roles, role) {
+		return ErrBudgetForbidden
+	}
+	return nil
+}
+
+func newBudgetMemberResponses(members []models.BudgetMember) []models.BudgetMemberResponse {
+	resp := make([]models.BudgetMemberResponse, 0, len(members))
+	for _, member := range members {
+		resp = append(resp, newBudgetMemberResponse(member))
+	}
+	return resp
+}
+
+func newBudgetMemberResponse(member models.BudgetMember) models.BudgetMemberResponse {
+	return models.BudgetMemberResponse{
+		BudgetID:  member.BudgetID,
+		UserID:    member.UserID,
+		Email:     member.User.Email,
+		Role:      member.Role,
+		InvitedBy: member.InvitedBy,
+		Accepted:  member.AcceptedAt != nil && member.AcceptedAt.Valid,
+	}
+}
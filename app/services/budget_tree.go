@@ -0,0 +1,243 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"finapp/constants"
+	"finapp/models"
+)
+
+// Дерево бюджетов пользователя с остатками на дату date (по умолчанию сегодня).
+// Total узла - остаток вместе со всеми вложенными бюджетами
+func (s BudgetService) Tree(c *gin.Context, userID uint) ([]models.BudgetTreeResponse, error) {
+	date := currentDate()
+	if dateStr := c.Query("date"); dateStr != "" {
+		var err error
+		date, err = time.Parse(constants.DateFormat, dateStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	budgets, err := s.repository.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	children := childBudgets(budgets)
+
+	nodes := make([]models.BudgetTreeResponse, 0, len(children[0]))
+	visited := make(map[uint]bool)
+	for _, budget := range children[0] {
+		node, _, err := s.treeNode(budget, children, visited, userID, c.Query("currency"), date)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// Узел дерева и его Total в валюте узла
+func (s BudgetService) treeNode(
+	budget models.Budget,
+	children map[uint][]models.Budget,
+	visited map[uint]bool,
+	userID uint,
+	currency string,
+	date time.Time,
+) (models.BudgetTreeResponse, decimal.Decimal, error) {
+	visited[budget.ID] = true
+
+	node := models.BudgetTreeResponse{
+		ID:       budget.ID,
+		Title:    budget.Title,
+		Parent:   models.IDFromNull(budget.ParentID),
+		Currency: budget.Currency,
+		Children: make([]models.BudgetTreeResponse, 0),
+	}
+	if currency != "" {
+		node.Currency = normalizeCurrency(currency)
+	}
+	converter := newCurrencyConverter(s.rateRepository, userID, node.Currency)
+
+	amount, err := s.repository.GetBudgetAmount(budget.ID, userID, date)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return models.BudgetTreeResponse{}, decimal.Decimal{}, err
+		}
+		amount = decimal.Zero
+	}
+	if amount, err = converter.Convert(amount, budget.Currency, date); err != nil {
+		return models.BudgetTreeResponse{}, decimal.Decimal{}, err
+	}
+
+	total := amount
+	for _, child := range children[budget.ID] {
+		if visited[child.ID] {
+			continue
+		}
+		childNode, childTotal, err := s.treeNode(child, children, visited, userID, currency, date)
+		if err != nil {
+			return models.BudgetTreeResponse{}, decimal.Decimal{}, err
+		}
+		if childTotal, err = converter.Convert(childTotal, childNode.Currency, date); err != nil {
+			return models.BudgetTreeResponse{}, decimal.Decimal{}, err
+		}
+		total = total.Add(childTotal)
+		node.Children = append(node.Children, childNode)
+	}
+
+	node.Amount = amount
+	node.Total = total
+	return node, total, nil
+}
+
+// Начальная сумма и изменения бюджетов members в валюте currency.
+// Ряды нескольких бюджетов складываются по датам
+func (s BudgetService) budgetChanges(
+	userID uint,
+	members []models.Budget,
+	currency string,
+	dateFrom, dateTo time.Time,
+) (decimal.Decimal, []models.BudgetChanges, error) {
+	var (
+		startAmount decimal.Decimal
+		changes     []models.BudgetChanges
+	)
+	for _, budget := range members {
+		amount, err := s.repository.GetBudgetAmount(budget.ID, userID, dateFrom)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return decimal.Decimal{}, nil, err
+			}
+			amount = decimal.New(0, 0)
+		}
+
+		budgetChanges, err := s.trxRepository.GetBudgetChanges(budget.ID, userID, dateFrom, dateTo)
+		if err != nil {
+			return decimal.Decimal{}, nil, err
+		}
+
+		amount, budgetChanges, err = s.convertChanges(userID, budget.Currency, currency, dateFrom, amount, budgetChanges)
+		if err != nil {
+			return decimal.Decimal{}, nil, err
+		}
+		startAmount = startAmount.Add(amount)
+		changes = append(changes, budgetChanges...)
+	}
+
+	if len(members) > 1 {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Date.Before(changes[j].Date)
+		})
+	}
+	return startAmount, changes, nil
+}
+
+// Бюджет и, с include_children=true, все вложенные в него бюджеты.
+// Вложенные бюджеты загружаются по уровням только при запросе
+func (s BudgetService) budgetMembers(c *gin.Context, budget models.Budget, userID uint) ([]models.Budget, error) {
+	members := []models.Budget{budget}
+	includeStr := c.Query("include_children")
+	if includeStr == "" {
+		return members, nil
+	}
+	include, err := strconv.ParseBool(includeStr)
+	if err != nil || !include {
+		return members, err
+	}
+
+	visited := map[uint]bool{budget.ID: true}
+	for level := []uint{budget.ID}; len(level) > 0; {
+		children, err := s.repository.Children(level, userID)
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, child := range children {
+			if !visited[child.ID] {
+				visited[child.ID] = true
+				members = append(members, child)
+				level = append(level, child.ID)
+			}
+		}
+	}
+	return members, nil
+}
+
+// Проверяет родителя бюджета id: он не удалён, принадлежит владельцу бюджета
+// (а не любому участнику общего бюджета) и не вложен в сам бюджет.
+// id = 0 для нового бюджета пользователя
+func (s BudgetService) checkParent(userID, id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == id {
+		return ReferenceError{"Parent": "acyclic"}
+	}
+
+	owner := userID
+	if id != 0 {
+		budget, err := s.repository.Get(id, userID)
+		if err != nil {
+			return err
+		}
+		owner = budget.UserID
+	}
+	parent, err := s.repository.Get(*parentID, userID)
+	if err != nil {
+		return checkExists("Parent", err)
+	}
+	if parent.UserID != owner {
+		return ReferenceError{"Parent": "exists"}
+	}
+	if id == 0 {
+		return nil
+	}
+
+	budgets, err := s.repository.List(owner)
+	if err != nil {
+		return err
+	}
+	parents := make(map[uint]uint, len(budgets))
+	for _, budget := range budgets {
+		if parent := models.IDFromNull(budget.ParentID); parent != nil {
+			parents[budget.ID] = *parent
+		}
+	}
+
+	visited := make(map[uint]bool)
+	for curr, ok := *parentID, true; ok && !visited[curr]; curr, ok = parents[curr] {
+		if curr == id {
+			return ReferenceError{"Parent": "acyclic"}
+		}
+		visited[curr] = true
+	}
+	return nil
+}
+
+// Вложенные бюджеты по родителю. Бюджеты верхнего уровня и бюджеты
+// удалённых родителей лежат под ключом 0
+func childBudgets(budgets []models.Budget) map[uint][]models.Budget {
+	ids := make(map[uint]bool, len(budgets))
+	for _, budget := range budgets {
+		ids[budget.ID] = true
+	}
+
+	children := make(map[uint][]models.Budget)
+	for _, budget := range budgets {
+		var parent uint
+		if id := models.IDFromNull(budget.ParentID); id != nil && ids[*id] {
+			parent = *id
+		}
+		children[parent] = append(children[parent], budget)
+	}
+	return children
+}
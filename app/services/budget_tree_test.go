@@ -0,0 +1,88 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+func newTestDatabase(t *testing.T) lib.Database {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Budget{}, &models.BudgetMember{}); err != nil {
+		t.Fatal(err)
+	}
+	return lib.Database{DB: db}
+}
+
+func TestCheckParent(t *testing.T) {
+	const me, other = 1, 2
+	db := newTestDatabase(t)
+
+	// 1, 2 - свои, 2 вложен в 1; 3-5, 7 - чужие: 3 доступен на чтение,
+	// 4 и 7 на изменение, 5 не доступен
+	budgets := []models.Budget{
+		{UserID: me}, {UserID: me, ParentID: validID(1)},
+		{UserID: other}, {UserID: other}, {UserID: other}, {UserID: me}, {UserID: other},
+	}
+	if err := db.Create(&budgets).Error; err != nil {
+		t.Fatal(err)
+	}
+	accepted := &sql.NullTime{Time: time.Now(), Valid: true}
+	members := []models.BudgetMember{
+		{BudgetID: 3, UserID: me, Role: models.BudgetRoleViewer, AcceptedAt: accepted},
+		{BudgetID: 4, UserID: me, Role: models.BudgetRoleEditor, AcceptedAt: accepted},
+		{BudgetID: 7, UserID: me, Role: models.BudgetRoleEditor, AcceptedAt: accepted},
+	}
+	if err := db.Create(&members).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	service := BudgetService{repository: repository.BudgetRepository{Database: db}}
+
+	tests := []struct {
+		name   string
+		id     uint
+		parent uint
+		want   string
+	}{
+		{"new budget under own budget", 0, 1, ""},
+		{"new budget under viewed shared budget", 0, 3, "exists"},
+		{"new budget under edited shared budget", 0, 4, "exists"},
+		{"new budget under inaccessible budget", 0, 5, "exists"},
+		{"new budget under missing budget", 0, 99, "exists"},
+		{"own budget under itself", 1, 1, "acyclic"},
+		{"own budget under its child", 1, 2, "acyclic"},
+		{"own budget under shared budget", 6, 4, "exists"},
+		{"shared budget under budget of its owner", 4, 7, ""},
+		{"shared budget under own budget", 4, 1, "exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := tt.parent
+			err := service.checkParent(me, tt.id, &parent)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			refErr, ok := err.(ReferenceError)
+			if !ok || refErr["Parent"] != tt.want {
+				t.Fatalf("err = %v, want Parent: %s", err, tt.want)
+			}
+		})
+	}
+}
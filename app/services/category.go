@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+type CategoryService struct {
+	logger     lib.Logger
+	repository repository.CategoryRepository
+}
+
+func NewCategoryService(
+	logger lib.Logger,
+	repository repository.CategoryRepository,
+) domains.CategoryService {
+	return CategoryService{
+		logger:     logger,
+		repository: repository,
+	}
+}
+
+func (s CategoryService) WithTrx(trxHandle *gorm.DB) domains.CategoryService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	return s
+}
+
+func (s CategoryService) List(userID uint) ([]models.CategoryResponse, error) {
+	categories, err := s.repository.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []models.CategoryResponse
+	for _, v := range categories {
+		resp = append(resp, models.CategoryResponse{
+			ID:    v.ID,
+			Title: v.Title,
+		})
+	}
+	return resp, nil
+}
+
+func (s CategoryService) Get(c *gin.Context, userID uint) (models.CategoryResponse, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return models.CategoryResponse{}, errors.New("category id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	category, err := s.repository.Get(uint(id), userID)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	resp := models.CategoryResponse{
+		ID:    category.ID,
+		Title: category.Title,
+	}
+	return resp, nil
+}
+
+func (s CategoryService) Store(request *models.CategoryStoreRequest, userID uint) (models.CategoryResponse, error) {
+	category := models.Category{
+		UserID: userID,
+		Title:  request.Title,
+	}
+
+	if err := s.repository.Create(&category); err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	resp := models.CategoryResponse{
+		ID:    category.ID,
+		Title: category.Title,
+	}
+	return resp, nil
+}
+
+func (s CategoryService) Patch(c *gin.Context, request models.CategoryPatchRequest, userID uint) (models.CategoryResponse, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return models.CategoryResponse{}, errors.New("category id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	category, err := s.repository.Patch(models.Category{Title: request.Title}, uint(id), userID)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+
+	resp := models.CategoryResponse{
+		ID:    category.ID,
+		Title: category.Title,
+	}
+	return resp, nil
+}
+
+func (s CategoryService) Delete(c *gin.Context, userID uint) error {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return errors.New("category id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+
+	return s.repository.Delete(uint(id), userID)
+}
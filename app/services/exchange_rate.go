@@ -0,0 +1,142 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+type ExchangeRateService struct {
+	logger     lib.Logger
+	repository repository.ExchangeRateRepository
+}
+
+func NewExchangeRateService(
+	logger lib.Logger,
+	repository repository.ExchangeRateRepository,
+) domains.ExchangeRateService {
+	return ExchangeRateService{
+		logger:     logger,
+		repository: repository,
+	}
+}
+
+func (s ExchangeRateService) WithTrx(trxHandle *gorm.DB) domains.ExchangeRateService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	return s
+}
+
+func (s ExchangeRateService) List(c *gin.Context, userID uint) ([]models.ExchangeRateResponse, error) {
+	var (
+		dateFrom time.Time
+		dateTo   time.Time
+		err      error
+	)
+	if dateFromStr := c.Query("date_from"); dateFromStr != "" {
+		if dateFrom, err = time.Parse(constants.DateFormat, dateFromStr); err != nil {
+			return nil, err
+		}
+	}
+	if dateToStr := c.Query("date_to"); dateToStr != "" {
+		if dateTo, err = time.Parse(constants.DateFormat, dateToStr); err != nil {
+			return nil, err
+		}
+	}
+
+	rates, err := s.repository.List(userID, dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.ExchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		resp = append(resp, models.ExchangeRateResponse{
+			Date:  rate.Date.Format(constants.DateFormat),
+			Base:  rate.Base,
+			Quote: rate.Quote,
+			Rate:  rate.Rate,
+		})
+	}
+	return resp, nil
+}
+
+func (s ExchangeRateService) Import(request models.ExchangeRateImportRequest, userID uint) (models.ExchangeRateImportResponse, error) {
+	rates := make([]models.ExchangeRate, 0, len(request.Rates))
+	for _, r := range request.Rates {
+		date, err := time.Parse(constants.DateFormat, r.Date)
+		if err != nil {
+			return models.ExchangeRateImportResponse{}, err
+		}
+		rates = append(rates, models.ExchangeRate{
+			UserID: userID,
+			Date:   date,
+			Base:   normalizeCurrency(r.Base),
+			Quote:  normalizeCurrency(r.Quote),
+			Rate:   r.Rate,
+		})
+	}
+
+	if err := s.repository.Upsert(rates); err != nil {
+		return models.ExchangeRateImportResponse{}, err
+	}
+	return models.ExchangeRateImportResponse{Imported: len(rates)}, nil
+}
+
+func normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return constants.DefaultCurrency
+	}
+	return currency
+}
+
+type rateKey struct {
+	from string
+	date time.Time
+}
+
+// Пересчёт сумм в одну валюту по курсам пользователя на дату суммы.
+// Курсы кэшируются на время одного запроса
+type currencyConverter struct {
+	repository repository.ExchangeRateRepository
+	userID     uint
+	to         string
+	rates      map[rateKey]decimal.Decimal
+}
+
+func newCurrencyConverter(repository repository.ExchangeRateRepository, userID uint, to string) *currencyConverter {
+	return &currencyConverter{
+		repository: repository,
+		userID:     userID,
+		to:         to,
+		rates:      make(map[rateKey]decimal.Decimal),
+	}
+}
+
+func (c *currencyConverter) Convert(amount decimal.Decimal, from string, date time.Time) (decimal.Decimal, error) {
+	from = normalizeCurrency(from)
+	if from == c.to || amount.IsZero() {
+		return amount, nil
+	}
+
+	key := rateKey{from: from, date: date}
+	rate, ok := c.rates[key]
+	if !ok {
+		var err error
+		rate, err = c.repository.GetRate(c.userID, from, c.to, date)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		c.rates[key] = rate
+	}
+	return amount.Mul(rate), nil
+}
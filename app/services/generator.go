@@ -2,6 +2,7 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"finapp/constants"
 	"finapp/domains"
 	"finapp/lib"
@@ -9,26 +10,38 @@ import (
 	"finapp/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 	"strconv"
 	"time"
 )
 
 type GeneratorService struct {
-	logger     lib.Logger
-	repository repository.GeneratorRepository
+	logger           lib.Logger
+	repository       repository.GeneratorRepository
+	budgetRepository repository.BudgetRepository
 }
 
 func NewGeneratorService(logger lib.Logger,
 	repository repository.GeneratorRepository,
+	budgetRepository repository.BudgetRepository,
 ) domains.GeneratorService {
 	return GeneratorService{
-		logger:     logger,
-		repository: repository,
+		logger:           logger,
+		repository:       repository,
+		budgetRepository: budgetRepository,
 	}
 }
 
+func (gs GeneratorService) WithTrx(trxHandle *gorm.DB) domains.GeneratorService {
+	gs.repository = gs.repository.WithTrx(trxHandle)
+	gs.budgetRepository = gs.budgetRepository.WithTrx(trxHandle)
+	return gs
+}
+
 func (gs GeneratorService) Store(generator models.GeneratorStoreRequest, userID uint) (models.GeneratorResponse, error) {
-	amount := decimal.NewFromFloat(generator.Amount)
+	if err := checkTransfer(gs.budgetRepository, userID, generator.BudgetFrom, generator.BudgetTo); err != nil {
+		return models.GeneratorResponse{}, err
+	}
 
 	dateFrom, err := time.Parse(constants.DateFormat, generator.DateFrom)
 	if err != nil {
@@ -47,11 +60,11 @@ func (gs GeneratorService) Store(generator models.GeneratorStoreRequest, userID
 	model := &models.Generator{
 		UserID:            userID,
 		Title:             generator.Title,
-		Amount:            amount,
+		Amount:            generator.Amount,
 		Periodicity:       generator.Periodicity,
 		PeriodicityFactor: generator.PeriodicityFactor,
-		BudgetFrom:        convertBudgetIDToModel(generator.BudgetFrom),
-		BudgetTo:          convertBudgetIDToModel(generator.BudgetTo),
+		BudgetFrom:        models.NullID(generator.BudgetFrom),
+		BudgetTo:          models.NullID(generator.BudgetTo),
 		DateFrom:          dateFrom,
 		DateTo:            dateTo,
 	}
@@ -63,11 +76,11 @@ func (gs GeneratorService) Store(generator models.GeneratorStoreRequest, userID
 	resp := models.GeneratorResponse{
 		ID:                model.ID,
 		Title:             model.Title,
-		Amount:            model.Amount.InexactFloat64(),
+		Amount:            model.Amount,
 		Periodicity:       model.Periodicity,
 		PeriodicityFactor: model.PeriodicityFactor,
-		BudgetFrom:        convertBudgetIDFromModel(model.BudgetFrom),
-		BudgetTo:          convertBudgetIDFromModel(model.BudgetTo),
+		BudgetFrom:        models.IDFromNull(model.BudgetFrom),
+		BudgetTo:          models.IDFromNull(model.BudgetTo),
 		DateFrom:          model.DateFrom.Format(constants.DateFormat),
 		DateTo:            convertNullTime(model.DateTo),
 	}
@@ -75,28 +88,55 @@ func (gs GeneratorService) Store(generator models.GeneratorStoreRequest, userID
 	return resp, nil
 }
 
-func (gs GeneratorService) List(userID uint) ([]models.GeneratorResponse, error) {
-	gens, err := gs.repository.List(userID)
+// Поля сортировки списка генераторов
+var generatorSorts = map[string]sortField{
+	"created_at": {Column: "created_at", Kind: sortKindTime},
+	"date_from":  {Column: "date_from", Kind: sortKindTime},
+	"amount":     {Column: "CAST(amount AS DECIMAL)", Kind: sortKindDecimal},
+	"title":      {Column: "title", Kind: sortKindString},
+}
+
+func (gs GeneratorService) List(c *gin.Context, userID uint) (models.PageResponse[models.GeneratorResponse], error) {
+	page, err := parsePageRequest(c, generatorSorts, "created_at")
 	if err != nil {
-		return nil, err
-	}
-
-	var resp []models.GeneratorResponse
-	for _, v := range gens {
-		gen := models.GeneratorResponse{
-			ID:                v.ID,
-			Title:             v.Title,
-			Amount:            v.Amount.InexactFloat64(),
-			Periodicity:       v.Periodicity,
-			PeriodicityFactor: v.PeriodicityFactor,
-			BudgetFrom:        convertBudgetIDFromModel(v.BudgetFrom),
-			BudgetTo:          convertBudgetIDFromModel(v.BudgetTo),
-			DateFrom:          v.DateFrom.Format(constants.DateFormat),
-			DateTo:            convertNullTime(v.DateTo),
-		}
-		resp = append(resp, gen)
+		return models.PageResponse[models.GeneratorResponse]{}, err
+	}
+
+	gens, err := gs.repository.List(userID, page)
+	if err != nil {
+		return models.PageResponse[models.GeneratorResponse]{}, err
+	}
+
+	return newPageResponse(gens, page, func(gen models.Generator) (any, uint) {
+		return generatorSortValue(gen, page.Sort), gen.ID
+	}, newGeneratorResponse), nil
+}
+
+func generatorSortValue(gen models.Generator, sort string) any {
+	switch sort {
+	case "date_from":
+		return gen.DateFrom
+	case "amount":
+		return gen.Amount
+	case "title":
+		return gen.Title
+	default:
+		return gen.CreatedAt
+	}
+}
+
+func newGeneratorResponse(gen models.Generator) models.GeneratorResponse {
+	return models.GeneratorResponse{
+		ID:                gen.ID,
+		Title:             gen.Title,
+		Amount:            gen.Amount,
+		Periodicity:       gen.Periodicity,
+		PeriodicityFactor: gen.PeriodicityFactor,
+		BudgetFrom:        models.IDFromNull(gen.BudgetFrom),
+		BudgetTo:          models.IDFromNull(gen.BudgetTo),
+		DateFrom:          gen.DateFrom.Format(constants.DateFormat),
+		DateTo:            convertNullTime(gen.DateTo),
 	}
-	return resp, nil
 }
 
 func (gs GeneratorService) Get(c *gin.Context, userID uint) (models.GeneratorResponse, error) {
@@ -114,11 +154,11 @@ func (gs GeneratorService) Get(c *gin.Context, userID uint) (models.GeneratorRes
 	resp := models.GeneratorResponse{
 		ID:                gen.ID,
 		Title:             gen.Title,
-		Amount:            gen.Amount.InexactFloat64(),
+		Amount:            gen.Amount,
 		Periodicity:       gen.Periodicity,
 		PeriodicityFactor: gen.PeriodicityFactor,
-		BudgetFrom:        convertBudgetIDFromModel(gen.BudgetFrom),
-		BudgetTo:          convertBudgetIDFromModel(gen.BudgetTo),
+		BudgetFrom:        models.IDFromNull(gen.BudgetFrom),
+		BudgetTo:          models.IDFromNull(gen.BudgetTo),
 		DateFrom:          gen.DateFrom.Format(constants.DateFormat),
 		DateTo:            convertNullTime(gen.DateTo),
 	}
@@ -132,6 +172,14 @@ func (gs GeneratorService) Update(c *gin.Context, generator models.GeneratorPatc
 	if err != nil {
 		return models.GeneratorResponse{}, err
 	}
+	if err := gs.checkEditable(uint(id), userID); err != nil {
+		return models.GeneratorResponse{}, err
+	}
+
+	// Бюджеты генератора перезаписываются значениями из запроса
+	if err := checkTransfer(gs.budgetRepository, userID, generator.BudgetFrom, generator.BudgetTo); err != nil {
+		return models.GeneratorResponse{}, err
+	}
 
 	var dateTo time.Time
 	if generator.DateTo != "" {
@@ -150,8 +198,8 @@ func (gs GeneratorService) Update(c *gin.Context, generator models.GeneratorPatc
 	}
 
 	var amount decimal.Decimal
-	if generator.Amount != 0 {
-		amount = decimal.NewFromFloat(generator.Amount)
+	if !generator.Amount.IsZero() {
+		amount = generator.Amount
 	}
 
 	gen := models.Generator{
@@ -159,8 +207,8 @@ func (gs GeneratorService) Update(c *gin.Context, generator models.GeneratorPatc
 		Amount:            amount,
 		Periodicity:       generator.Periodicity,
 		PeriodicityFactor: generator.PeriodicityFactor,
-		BudgetFrom:        convertBudgetIDToModel(generator.BudgetFrom),
-		BudgetTo:          convertBudgetIDToModel(generator.BudgetTo),
+		BudgetFrom:        models.NullID(generator.BudgetFrom),
+		BudgetTo:          models.NullID(generator.BudgetTo),
 		DateTo:            &sql.NullTime{Time: dateTo, Valid: true},
 		DateFrom:          dateFrom,
 	}
@@ -173,11 +221,11 @@ func (gs GeneratorService) Update(c *gin.Context, generator models.GeneratorPatc
 	resp := models.GeneratorResponse{
 		ID:                model.ID,
 		Title:             model.Title,
-		Amount:            model.Amount.InexactFloat64(),
+		Amount:            model.Amount,
 		Periodicity:       model.Periodicity,
 		PeriodicityFactor: model.PeriodicityFactor,
-		BudgetFrom:        convertBudgetIDFromModel(model.BudgetFrom),
-		BudgetTo:          convertBudgetIDFromModel(model.BudgetTo),
+		BudgetFrom:        models.IDFromNull(model.BudgetFrom),
+		BudgetTo:          models.IDFromNull(model.BudgetTo),
 		DateFrom:          model.DateFrom.Format(constants.DateFormat),
 		DateTo:            convertNullTime(model.DateTo),
 	}
@@ -191,6 +239,9 @@ func (gs GeneratorService) Delete(c *gin.Context, userID uint) error {
 	if err != nil {
 		return err
 	}
+	if err := gs.checkEditable(uint(id), userID); err != nil {
+		return err
+	}
 
 	err = gs.repository.Delete(uint(id), userID)
 	if err != nil {
@@ -200,28 +251,6 @@ func (gs GeneratorService) Delete(c *gin.Context, userID uint) error {
 	return nil
 }
 
-func convertBudgetIDToModel(id *uint) *sql.NullInt64 {
-	if id != nil {
-		return &sql.NullInt64{
-			Int64: int64(*id),
-			Valid: true,
-		}
-	}
-	return &sql.NullInt64{}
-}
-
-func convertBudgetIDFromModel(budget *sql.NullInt64) *uint {
-	if budget == nil {
-		return nil
-	}
-	if budget.Valid {
-		var id uint
-		id = uint(budget.Int64)
-		return &id
-	}
-	return nil
-}
-
 func convertNullTime(time *sql.NullTime) *string {
 	if time == nil {
 		return nil
@@ -232,3 +261,15 @@ func convertNullTime(time *sql.NullTime) *string {
 	}
 	return nil
 }
+
+// Генератор общего бюджета изменяют только его владельцы и редакторы
+func (gs GeneratorService) checkEditable(id, userID uint) error {
+	if _, err := gs.repository.Get(id, userID); err != nil {
+		return err
+	}
+	_, err := gs.repository.Get(id, userID, models.BudgetWriteRoles...)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrBudgetForbidden
+	}
+	return err
+}
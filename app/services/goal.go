@@ -23,6 +23,7 @@ type GoalService struct {
 	repository       repository.GoalRepository
 	budgetRepository repository.BudgetRepository
 	trxRepository    repository.TrxRepository
+	rateRepository   repository.ExchangeRateRepository
 }
 
 func NewGoalService(
@@ -30,12 +31,14 @@ func NewGoalService(
 	repository repository.GoalRepository,
 	budgetRepository repository.BudgetRepository,
 	trxRepository repository.TrxRepository,
+	rateRepository repository.ExchangeRateRepository,
 ) domains.GoalService {
 	return GoalService{
 		logger:           logger,
 		repository:       repository,
 		budgetRepository: budgetRepository,
 		trxRepository:    trxRepository,
+		rateRepository:   rateRepository,
 	}
 }
 
@@ -44,7 +47,14 @@ func (s GoalService) WithTrx(trxHandle *gorm.DB) domains.GoalService {
 	return s
 }
 
-func (s GoalService) List(c *gin.Context, userID uint) ([]models.GoalCalcResponse, error) {
+// Поля сортировки списка целей
+var goalSorts = map[string]sortField{
+	"created_at":    {Column: "created_at", Kind: sortKindTime},
+	"target_amount": {Column: "CAST(target_amount AS DECIMAL)", Kind: sortKindDecimal},
+	"title":         {Column: "title", Kind: sortKindString},
+}
+
+func (s GoalService) List(c *gin.Context, userID uint) (models.PageResponse[models.GoalCalcResponse], error) {
 	var (
 		dateFrom time.Time
 		dateTo   time.Time
@@ -52,64 +62,94 @@ func (s GoalService) List(c *gin.Context, userID uint) ([]models.GoalCalcRespons
 	if dateFromStr := c.Query("date_from"); dateFromStr != "" {
 		dateFromTemp, err := time.Parse(constants.DateFormat, dateFromStr)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.GoalCalcResponse]{}, err
 		}
 		dateFrom = dateFromTemp
 	}
 	if dateToStr := c.Query("date_to"); dateToStr != "" {
 		dateToTemp, err := time.Parse(constants.DateFormat, dateToStr)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.GoalCalcResponse]{}, err
 		}
 		dateTo = dateToTemp
 	}
 
 	if !dateTo.IsZero() && dateTo.Before(dateFrom) {
-		return nil, errors.New("date_from time goes after date_to")
+		return models.PageResponse[models.GoalCalcResponse]{}, errors.New("date_from time goes after date_to")
+	}
+
+	page, err := parsePageRequest(c, goalSorts, "created_at")
+	if err != nil {
+		return models.PageResponse[models.GoalCalcResponse]{}, err
 	}
 
-	goals, err := s.repository.List(userID)
+	goals, err := s.repository.List(userID, page)
 	if err != nil {
-		return nil, err
+		return models.PageResponse[models.GoalCalcResponse]{}, err
 	}
+	goalPage := newPageResponse(goals, page, func(goal models.Goal) (any, uint) {
+		return goalSortValue(goal, page.Sort), goal.ID
+	}, func(goal models.Goal) models.Goal {
+		return goal
+	})
 
-	var resp []models.GoalCalcResponse
-	for _, goal := range goals {
+	resp := models.PageResponse[models.GoalCalcResponse]{
+		Items:      make([]models.GoalCalcResponse, 0, len(goalPage.Items)),
+		NextCursor: goalPage.NextCursor,
+	}
+	for _, goal := range goalPage.Items {
 		budgets, err := s.budgetRepository.ListOfGoal(userID, goal.ID)
 		if err != nil {
-			return nil, err
+			return models.PageResponse[models.GoalCalcResponse]{}, err
 		}
 
 		g := models.GoalCalcResponse{
 			ID:           goal.ID,
 			Title:        goal.Title,
-			TargetAmount: goal.TargetAmount.InexactFloat64(),
-			Amounts:      make(map[string]float64),
+			Currency:     goalCurrency(c, budgets),
+			TargetAmount: goal.TargetAmount,
+			Amounts:      make(map[string]decimal.Decimal),
 		}
+		converter := newCurrencyConverter(s.rateRepository, userID, g.Currency)
 
 		changes := make(map[time.Time]decimal.Decimal)
+		var income, expense decimal.Decimal
 		for _, v := range budgets {
 			amount, err := s.budgetRepository.GetBudgetAmount(v.ID, userID, dateFrom)
 			if err != nil {
 				if !errors.Is(err, sql.ErrNoRows) {
-					return nil, err
+					return models.PageResponse[models.GoalCalcResponse]{}, err
 				}
 				amount = decimal.Zero
 			}
+			if amount, err = converter.Convert(amount, v.Currency, dateFrom); err != nil {
+				return models.PageResponse[models.GoalCalcResponse]{}, err
+			}
 			if !dateFrom.IsZero() {
 				g.Amounts[dateFrom.Format(constants.DateFormat)] =
-					g.Amounts[dateFrom.Format(constants.DateFormat)] + amount.InexactFloat64()
+					g.Amounts[dateFrom.Format(constants.DateFormat)].Add(amount)
 			}
 
 			budgetChanges, err := s.trxRepository.GetBudgetChanges(v.ID, userID, dateFrom, dateTo)
 			if err != nil {
-				return nil, err
+				return models.PageResponse[models.GoalCalcResponse]{}, err
 			}
 
 			for _, change := range budgetChanges {
-				changes[change.Date] = changes[change.Date].Add(change.AmountChange)
+				amountChange, err := converter.Convert(change.AmountChange, v.Currency, change.Date)
+				if err != nil {
+					return models.PageResponse[models.GoalCalcResponse]{}, err
+				}
+				changes[change.Date] = changes[change.Date].Add(amountChange)
+			}
+
+			budgetIncome, budgetExpense, err := convertIncomeExpense(converter, v.Currency, budgetChanges)
+			if err != nil {
+				return models.PageResponse[models.GoalCalcResponse]{}, err
 			}
+			income, expense = income.Add(budgetIncome), expense.Add(budgetExpense)
 		}
+		g.Income, g.Expense = income, expense
 
 		dates := make([]time.Time, 0, len(changes))
 		for k, _ := range changes {
@@ -132,10 +172,10 @@ func (s GoalService) List(c *gin.Context, userID uint) ([]models.GoalCalcRespons
 				for currDate.Before(v) {
 					currDate = currDate.Add(24 * time.Hour)
 					g.Amounts[currDate.Format(constants.DateFormat)] =
-						g.Amounts[currDate.Format(constants.DateFormat)] + currAmountState
+						g.Amounts[currDate.Format(constants.DateFormat)].Add(currAmountState)
 				}
 			}
-			currAmountState = currAmountState + changes[v].InexactFloat64()
+			currAmountState = currAmountState.Add(changes[v])
 			g.Amounts[currDate.Format(constants.DateFormat)] = currAmountState
 			currDate = v
 		}
@@ -147,10 +187,21 @@ func (s GoalService) List(c *gin.Context, userID uint) ([]models.GoalCalcRespons
 			}
 		}
 
-		resp = append(resp, g)
+		resp.Items = append(resp.Items, g)
 	}
 
-	return resp, err
+	return resp, nil
+}
+
+func goalSortValue(goal models.Goal, sort string) any {
+	switch sort {
+	case "target_amount":
+		return goal.TargetAmount
+	case "title":
+		return goal.Title
+	default:
+		return goal.CreatedAt
+	}
 }
 
 func (s GoalService) Get(c *gin.Context, userID uint) (models.GoalCalcResponse, error) {
@@ -199,11 +250,14 @@ func (s GoalService) Get(c *gin.Context, userID uint) (models.GoalCalcResponse,
 	resp := models.GoalCalcResponse{
 		ID:           goal.ID,
 		Title:        goal.Title,
-		TargetAmount: goal.TargetAmount.InexactFloat64(),
-		Amounts:      make(map[string]float64),
+		Currency:     goalCurrency(c, budgets),
+		TargetAmount: goal.TargetAmount,
+		Amounts:      make(map[string]decimal.Decimal),
 	}
+	converter := newCurrencyConverter(s.rateRepository, userID, resp.Currency)
 
 	changes := make(map[time.Time]decimal.Decimal)
+	var income, expense decimal.Decimal
 	for _, v := range budgets {
 		amount, err := s.budgetRepository.GetBudgetAmount(v.ID, userID, dateFrom)
 		if err != nil {
@@ -212,9 +266,12 @@ func (s GoalService) Get(c *gin.Context, userID uint) (models.GoalCalcResponse,
 			}
 			amount = decimal.Zero
 		}
+		if amount, err = converter.Convert(amount, v.Currency, dateFrom); err != nil {
+			return models.GoalCalcResponse{}, err
+		}
 		if !dateFrom.IsZero() {
 			resp.Amounts[dateFrom.Format(constants.DateFormat)] =
-				resp.Amounts[dateFrom.Format(constants.DateFormat)] + amount.InexactFloat64()
+				resp.Amounts[dateFrom.Format(constants.DateFormat)].Add(amount)
 		}
 
 		budgetChanges, err := s.trxRepository.GetBudgetChanges(v.ID, userID, dateFrom, dateTo)
@@ -223,9 +280,20 @@ func (s GoalService) Get(c *gin.Context, userID uint) (models.GoalCalcResponse,
 		}
 
 		for _, change := range budgetChanges {
-			changes[change.Date] = changes[change.Date].Add(change.AmountChange)
+			amountChange, err := converter.Convert(change.AmountChange, v.Currency, change.Date)
+			if err != nil {
+				return models.GoalCalcResponse{}, err
+			}
+			changes[change.Date] = changes[change.Date].Add(amountChange)
+		}
+
+		budgetIncome, budgetExpense, err := convertIncomeExpense(converter, v.Currency, budgetChanges)
+		if err != nil {
+			return models.GoalCalcResponse{}, err
 		}
+		income, expense = income.Add(budgetIncome), expense.Add(budgetExpense)
 	}
+	resp.Income, resp.Expense = income, expense
 
 	dates := make([]time.Time, 0, len(changes))
 	for k, _ := range changes {
@@ -248,10 +316,10 @@ func (s GoalService) Get(c *gin.Context, userID uint) (models.GoalCalcResponse,
 			for currDate.Before(v) {
 				currDate = currDate.Add(24 * time.Hour)
 				resp.Amounts[currDate.Format(constants.DateFormat)] =
-					resp.Amounts[currDate.Format(constants.DateFormat)] + currAmountState
+					resp.Amounts[currDate.Format(constants.DateFormat)].Add(currAmountState)
 			}
 		}
-		currAmountState = currAmountState + changes[v].InexactFloat64()
+		currAmountState = currAmountState.Add(changes[v])
 		resp.Amounts[currDate.Format(constants.DateFormat)] = currAmountState
 		currDate = v
 	}
@@ -267,10 +335,9 @@ func (s GoalService) Get(c *gin.Context, userID uint) (models.GoalCalcResponse,
 }
 
 func (s GoalService) Store(request *models.GoalStoreRequest, userID uint) (models.GoalResponse, error) {
-	targetAmount := decimal.NewFromFloat(request.TargetAmount)
 	goal := models.Goal{
 		UserID:       userID,
-		TargetAmount: targetAmount,
+		TargetAmount: request.TargetAmount,
 		Title:        request.Title,
 	}
 
@@ -282,7 +349,7 @@ func (s GoalService) Store(request *models.GoalStoreRequest, userID uint) (model
 	resp := models.GoalResponse{
 		ID:           goal.ID,
 		Title:        goal.Title,
-		TargetAmount: goal.TargetAmount.InexactFloat64(),
+		TargetAmount: goal.TargetAmount,
 	}
 
 	return resp, nil
@@ -298,10 +365,10 @@ func (s GoalService) Update(c *gin.Context, req models.GoalUpdateRequest, userID
 		return models.GoalResponse{}, err
 	}
 
+	// Нулевая сумма не меняет цель
 	var targetAmount decimal.Decimal
-	if req.TargetAmount != 0 {
-		amount := decimal.NewFromFloat(req.TargetAmount)
-		targetAmount = amount
+	if !req.TargetAmount.IsZero() {
+		targetAmount = req.TargetAmount
 	}
 
 	goal := models.Goal{
@@ -311,13 +378,13 @@ func (s GoalService) Update(c *gin.Context, req models.GoalUpdateRequest, userID
 
 	updateGoal, err := s.repository.Patch(goal, uint(id), userID)
 	if err != nil {
-		return models.GoalResponse{}, nil
+		return models.GoalResponse{}, err
 	}
 
 	resp := models.GoalResponse{
 		ID:           updateGoal.ID,
 		Title:        updateGoal.Title,
-		TargetAmount: updateGoal.TargetAmount.InexactFloat64(),
+		TargetAmount: updateGoal.TargetAmount,
 	}
 	return resp, nil
 }
@@ -334,3 +401,37 @@ func (s GoalService) Delete(c *gin.Context, UserID uint) error {
 
 	return s.repository.Delete(uint(id), UserID)
 }
+
+// Доходы и расходы бюджета цели за период в валюте цели
+func convertIncomeExpense(converter *currencyConverter, currency string, changes []models.BudgetChanges) (income, expense decimal.Decimal, err error) {
+	for _, change := range changes {
+		changeIncome, err := converter.Convert(change.Income, currency, change.Date)
+		if err != nil {
+			return decimal.Decimal{}, decimal.Decimal{}, err
+		}
+		changeExpense, err := converter.Convert(change.Expense, currency, change.Date)
+		if err != nil {
+			return decimal.Decimal{}, decimal.Decimal{}, err
+		}
+		income, expense = income.Add(changeIncome), expense.Add(changeExpense)
+	}
+	return income, expense, nil
+}
+
+// Валюта сумм цели: запрошенная, общая валюта её бюджетов или валюта по умолчанию.
+// Бюджеты в других валютах пересчитываются по курсу на дату изменения
+func goalCurrency(c *gin.Context, budgets []models.Budget) string {
+	if currency := c.Query("currency"); currency != "" {
+		return normalizeCurrency(currency)
+	}
+	if len(budgets) == 0 {
+		return constants.DefaultCurrency
+	}
+	currency := budgets[0].Currency
+	for _, budget := range budgets[1:] {
+		if budget.Currency != currency {
+			return constants.DefaultCurrency
+		}
+	}
+	return currency
+}
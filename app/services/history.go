@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+type HistoryService struct {
+	logger        lib.Logger
+	repository    repository.HistoryRepository
+	trxRepository repository.TrxRepository
+}
+
+func NewHistoryService(
+	logger lib.Logger,
+	repository repository.HistoryRepository,
+	trxRepository repository.TrxRepository,
+) domains.HistoryService {
+	return HistoryService{
+		logger:        logger,
+		repository:    repository,
+		trxRepository: trxRepository,
+	}
+}
+
+func (s HistoryService) WithTrx(trxHandle *gorm.DB) domains.HistoryService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	s.trxRepository = s.trxRepository.WithTrx(trxHandle)
+	return s
+}
+
+// Версии записи, сначала последние
+func (s HistoryService) List(c *gin.Context, entity models.HistoryEntity, userID uint) ([]models.HistoryResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repository.List(entity, uint(id), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.HistoryResponse, 0, len(history))
+	for _, version := range history {
+		resp = append(resp, newHistoryResponse(version))
+	}
+	return resp, nil
+}
+
+// Возвращает запись к состоянию после изменения версии version.
+// Возврат записывается в историю новой версией, она и возвращается
+func (s HistoryService) Revert(c *gin.Context, entity models.HistoryEntity, userID uint) (models.HistoryResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.HistoryResponse{}, err
+	}
+	versionID, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return models.HistoryResponse{}, err
+	}
+
+	version, err := s.repository.Get(entity, uint(id), uint(versionID), userID)
+	if err != nil {
+		return models.HistoryResponse{}, err
+	}
+
+	if entity == models.HistoryTrx {
+		if err := s.checkTrxRevert(version); err != nil {
+			return models.HistoryResponse{}, err
+		}
+	}
+
+	if err := s.repository.Revert(entity, uint(id), userID, version.After); err != nil {
+		return models.HistoryResponse{}, err
+	}
+
+	history, err := s.repository.List(entity, uint(id), userID)
+	if err != nil {
+		return models.HistoryResponse{}, err
+	}
+	return newHistoryResponse(history[0]), nil
+}
+
+// Сверенную транзакцию нельзя изменить возвратом, как и вернуть её в сверенное состояние
+func (s HistoryService) checkTrxRevert(version models.History) error {
+	trx, err := s.trxRepository.Get(version.RecordID, version.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if isReconciled(trx) {
+		return ErrTrxReconciled
+	}
+
+	if version.After == nil {
+		return nil
+	}
+	var state struct {
+		Status models.TrxStatus `json:"status"`
+		Legs   []struct {
+			Status models.TrxStatus `json:"status"`
+		} `json:"legs"`
+	}
+	if err := json.Unmarshal([]byte(*version.After), &state); err != nil {
+		return err
+	}
+	if state.Status == models.TrxStatusReconciled {
+		return ErrTrxReconciled
+	}
+	for _, leg := range state.Legs {
+		if leg.Status == models.TrxStatusReconciled {
+			return ErrTrxReconciled
+		}
+	}
+	return nil
+}
+
+func newHistoryResponse(version models.History) models.HistoryResponse {
+	return models.HistoryResponse{
+		Version:   version.Version,
+		Action:    version.Action,
+		Before:    historyState(version.Before),
+		After:     historyState(version.After),
+		ChangedBy: version.ChangedBy,
+		RequestID: version.RequestID,
+		ChangedAt: version.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func historyState(state *string) json.RawMessage {
+	if state == nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(*state)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"finapp/models"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+type sortKind int
+
+const (
+	sortKindTime sortKind = iota
+	sortKindDecimal
+	sortKindString
+)
+
+// Поле, по которому разрешена сортировка списка
+type sortField struct {
+	Column string
+	Kind   sortKind
+}
+
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// Разбор параметров limit, cursor, sort и order.
+// sorts - разрешённые для списка поля сортировки
+func parsePageRequest(c *gin.Context, sorts map[string]sortField, defaultSort string) (models.PageRequest, error) {
+	page := models.PageRequest{
+		Limit: defaultPageLimit,
+		Desc:  true,
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return models.PageRequest{}, err
+		}
+		if limit <= 0 || limit > maxPageLimit {
+			return models.PageRequest{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	sortName := c.DefaultQuery("sort", defaultSort)
+	field, ok := sorts[sortName]
+	if !ok {
+		return models.PageRequest{}, fmt.Errorf("unknown sort field: %s", sortName)
+	}
+	page.Sort = sortName
+	page.SortColumn = field.Column
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		page.Desc = false
+	case "desc":
+		page.Desc = true
+	default:
+		return models.PageRequest{}, errors.New("order must be asc or desc")
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursorStr)
+		if err != nil {
+			return models.PageRequest{}, errors.New("invalid cursor")
+		}
+		var cursor pageCursor
+		if err := json.Unmarshal(raw, &cursor); err != nil {
+			return models.PageRequest{}, errors.New("invalid cursor")
+		}
+		if cursor.Sort != sortName {
+			return models.PageRequest{}, errors.New("cursor does not match sort field")
+		}
+
+		var value any
+		switch field.Kind {
+		case sortKindTime:
+			value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+		case sortKindDecimal:
+			value, err = decimal.NewFromString(cursor.Value)
+		case sortKindString:
+			value = cursor.Value
+		}
+		if err != nil {
+			return models.PageRequest{}, errors.New("invalid cursor")
+		}
+		page.Cursor = &models.PageCursor{Value: value, ID: cursor.ID}
+	}
+
+	return page, nil
+}
+
+// Собирает страницу ответа из выборки репозитория.
+// cursorOf возвращает значение поля сортировки и ID записи
+func newPageResponse[M any, T any](
+	items []M,
+	page models.PageRequest,
+	cursorOf func(M) (any, uint),
+	convert func(M) T,
+) models.PageResponse[T] {
+	resp := models.PageResponse[T]{
+		Items: make([]T, 0, len(items)),
+	}
+
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+		value, id := cursorOf(items[len(items)-1])
+		resp.NextCursor = encodePageCursor(page.Sort, value, id)
+	}
+
+	for _, item := range items {
+		resp.Items = append(resp.Items, convert(item))
+	}
+	return resp
+}
+
+// Кодирует курсор следующей страницы по последней записи текущей
+func encodePageCursor(sortName string, value any, id uint) *string {
+	cursor := pageCursor{Sort: sortName, ID: id}
+	switch v := value.(type) {
+	case time.Time:
+		cursor.Value = v.Format(time.RFC3339Nano)
+	case decimal.Decimal:
+		cursor.Value = v.String()
+	default:
+		cursor.Value = fmt.Sprint(v)
+	}
+
+	raw, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(raw)
+	return &encoded
+}
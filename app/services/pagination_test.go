@@ -0,0 +1,159 @@
+package services
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"finapp/models"
+)
+
+var testSorts = map[string]sortField{
+	"created_at": {Column: "created_at", Kind: sortKindTime},
+	"amount":     {Column: "amount", Kind: sortKindDecimal},
+	"title":      {Column: "title", Kind: sortKindString},
+}
+
+func testContext(query url.Values) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+	return c
+}
+
+func TestParsePageRequest(t *testing.T) {
+	createdAt := time.Date(2024, 3, 5, 10, 20, 30, 123456789, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    models.PageRequest
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: url.Values{},
+			want:  models.PageRequest{Limit: defaultPageLimit, Desc: true, Sort: "created_at", SortColumn: "created_at"},
+		},
+		{
+			name:  "limit, sort and order",
+			query: url.Values{"limit": {"10"}, "sort": {"title"}, "order": {"asc"}},
+			want:  models.PageRequest{Limit: 10, Sort: "title", SortColumn: "title"},
+		},
+		{
+			name:  "time cursor",
+			query: url.Values{"cursor": {*encodePageCursor("created_at", createdAt, 7)}},
+			want: models.PageRequest{Limit: defaultPageLimit, Desc: true, Sort: "created_at", SortColumn: "created_at",
+				Cursor: &models.PageCursor{Value: createdAt, ID: 7}},
+		},
+		{
+			name:  "decimal cursor",
+			query: url.Values{"sort": {"amount"}, "cursor": {*encodePageCursor("amount", decimal.RequireFromString("-1500.50"), 3)}},
+			want: models.PageRequest{Limit: defaultPageLimit, Desc: true, Sort: "amount", SortColumn: "amount",
+				Cursor: &models.PageCursor{Value: decimal.RequireFromString("-1500.50"), ID: 3}},
+		},
+		{
+			name:  "string cursor",
+			query: url.Values{"sort": {"title"}, "cursor": {*encodePageCursor("title", "Кофе", 2)}},
+			want: models.PageRequest{Limit: defaultPageLimit, Desc: true, Sort: "title", SortColumn: "title",
+				Cursor: &models.PageCursor{Value: "Кофе", ID: 2}},
+		},
+		{name: "zero limit", query: url.Values{"limit": {"0"}}, wantErr: true},
+		{name: "limit above max", query: url.Values{"limit": {"501"}}, wantErr: true},
+		{name: "limit not a number", query: url.Values{"limit": {"ten"}}, wantErr: true},
+		{name: "unknown sort", query: url.Values{"sort": {"id"}}, wantErr: true},
+		{name: "unknown order", query: url.Values{"order": {"up"}}, wantErr: true},
+		{name: "garbage cursor", query: url.Values{"cursor": {"!!!"}}, wantErr: true},
+		{name: "cursor of another sort", query: url.Values{"sort": {"title"}, "cursor": {*encodePageCursor("created_at", createdAt, 1)}}, wantErr: true},
+		{name: "cursor with invalid value", query: url.Values{"sort": {"amount"}, "cursor": {*encodePageCursor("amount", "abc", 1)}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePageRequest(testContext(tt.query), testSorts, "created_at")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Limit != tt.want.Limit || got.Desc != tt.want.Desc || got.Sort != tt.want.Sort || got.SortColumn != tt.want.SortColumn {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			if (got.Cursor == nil) != (tt.want.Cursor == nil) {
+				t.Fatalf("cursor = %+v, want %+v", got.Cursor, tt.want.Cursor)
+			}
+			if got.Cursor == nil {
+				return
+			}
+			if got.Cursor.ID != tt.want.Cursor.ID {
+				t.Fatalf("cursor id = %d, want %d", got.Cursor.ID, tt.want.Cursor.ID)
+			}
+			switch want := tt.want.Cursor.Value.(type) {
+			case time.Time:
+				if value, ok := got.Cursor.Value.(time.Time); !ok || !value.Equal(want) {
+					t.Fatalf("cursor value = %v, want %v", got.Cursor.Value, want)
+				}
+			case decimal.Decimal:
+				if value, ok := got.Cursor.Value.(decimal.Decimal); !ok || !value.Equal(want) {
+					t.Fatalf("cursor value = %v, want %v", got.Cursor.Value, want)
+				}
+			default:
+				if got.Cursor.Value != want {
+					t.Fatalf("cursor value = %v, want %v", got.Cursor.Value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPageResponse(t *testing.T) {
+	type item struct {
+		ID    uint
+		Title string
+	}
+	items := []item{{1, "a"}, {2, "b"}, {3, "c"}}
+	cursorOf := func(i item) (any, uint) { return i.Title, i.ID }
+	convert := func(i item) string { return i.Title }
+
+	tests := []struct {
+		name       string
+		limit      int
+		wantItems  []string
+		wantCursor *pageCursor
+	}{
+		{"more than limit", 2, []string{"a", "b"}, &pageCursor{Sort: "title", Value: "b", ID: 2}},
+		{"exactly limit", 3, []string{"a", "b", "c"}, nil},
+		{"below limit", 5, []string{"a", "b", "c"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := models.PageRequest{Limit: tt.limit, Sort: "title", SortColumn: "title"}
+			resp := newPageResponse(items, page, cursorOf, convert)
+			if len(resp.Items) != len(tt.wantItems) {
+				t.Fatalf("items = %v, want %v", resp.Items, tt.wantItems)
+			}
+			for i := range tt.wantItems {
+				if resp.Items[i] != tt.wantItems[i] {
+					t.Fatalf("items = %v, want %v", resp.Items, tt.wantItems)
+				}
+			}
+			if tt.wantCursor == nil {
+				if resp.NextCursor != nil {
+					t.Fatalf("unexpected next cursor %s", *resp.NextCursor)
+				}
+				return
+			}
+			if resp.NextCursor == nil || *resp.NextCursor != *encodePageCursor(tt.wantCursor.Sort, tt.wantCursor.Value, tt.wantCursor.ID) {
+				t.Fatalf("next cursor = %v, want %+v", resp.NextCursor, tt.wantCursor)
+			}
+		})
+	}
+}
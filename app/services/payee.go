@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+type PayeeService struct {
+	logger             lib.Logger
+	repository         repository.PayeeRepository
+	categoryRepository repository.CategoryRepository
+	budgetRepository   repository.BudgetRepository
+}
+
+func NewPayeeService(
+	logger lib.Logger,
+	repository repository.PayeeRepository,
+	categoryRepository repository.CategoryRepository,
+	budgetRepository repository.BudgetRepository,
+) domains.PayeeService {
+	return PayeeService{
+		logger:             logger,
+		repository:         repository,
+		categoryRepository: categoryRepository,
+		budgetRepository:   budgetRepository,
+	}
+}
+
+func (s PayeeService) WithTrx(trxHandle *gorm.DB) domains.PayeeService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	s.categoryRepository = s.categoryRepository.WithTrx(trxHandle)
+	s.budgetRepository = s.budgetRepository.WithTrx(trxHandle)
+	return s
+}
+
+func (s PayeeService) List(userID uint) ([]models.PayeeResponse, error) {
+	payees, err := s.repository.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []models.PayeeResponse
+	for _, v := range payees {
+		resp = append(resp, newPayeeResponse(v))
+	}
+	return resp, nil
+}
+
+func (s PayeeService) Get(c *gin.Context, userID uint) (models.PayeeResponse, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return models.PayeeResponse{}, errors.New("payee id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return models.PayeeResponse{}, err
+	}
+
+	payee, err := s.repository.Get(uint(id), userID)
+	if err != nil {
+		return models.PayeeResponse{}, err
+	}
+	return newPayeeResponse(payee), nil
+}
+
+func (s PayeeService) Store(request *models.PayeeStoreRequest, userID uint) (models.PayeeResponse, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return models.PayeeResponse{}, errors.New("payee title is empty")
+	}
+	if err := s.checkDefaults(userID, request.CategoryID, request.BudgetID); err != nil {
+		return models.PayeeResponse{}, err
+	}
+
+	payee := models.Payee{
+		UserID:     userID,
+		Title:      title,
+		CategoryID: models.NullID(request.CategoryID),
+		BudgetID:   models.NullID(request.BudgetID),
+	}
+	if err := s.repository.Create(&payee); err != nil {
+		return models.PayeeResponse{}, err
+	}
+	return newPayeeResponse(payee), nil
+}
+
+func (s PayeeService) Patch(c *gin.Context, request models.PayeePatchRequest, userID uint) (models.PayeeResponse, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return models.PayeeResponse{}, errors.New("payee id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return models.PayeeResponse{}, err
+	}
+
+	if _, err := s.repository.Get(uint(id), userID); err != nil {
+		return models.PayeeResponse{}, err
+	}
+	if err := s.checkDefaults(userID, request.CategoryID.Value, request.BudgetID.Value); err != nil {
+		return models.PayeeResponse{}, err
+	}
+
+	updates := make(map[string]any)
+	if request.Title != nil {
+		title := strings.TrimSpace(*request.Title)
+		if title == "" {
+			return models.PayeeResponse{}, errors.New("payee title is empty")
+		}
+		updates["title"] = title
+	}
+	if request.CategoryID.Set {
+		updates["category_id"] = models.NullID(request.CategoryID.Value)
+	}
+	if request.BudgetID.Set {
+		updates["budget_id"] = models.NullID(request.BudgetID.Value)
+	}
+
+	payee, err := s.repository.Patch(updates, uint(id), userID)
+	if err != nil {
+		return models.PayeeResponse{}, err
+	}
+	return newPayeeResponse(payee), nil
+}
+
+func (s PayeeService) Delete(c *gin.Context, userID uint) error {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return errors.New("payee id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.repository.Get(uint(id), userID); err != nil {
+		return err
+	}
+	return s.repository.Delete(uint(id), userID)
+}
+
+// Проверяет, что категория и бюджет по умолчанию принадлежат пользователю
+func (s PayeeService) checkDefaults(userID uint, categoryID, budgetID *uint) error {
+	refErr := make(ReferenceError)
+	if categoryID != nil {
+		if _, err := s.categoryRepository.Get(*categoryID, userID); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			refErr["CategoryID"] = "exists"
+		}
+	}
+	if budgetID != nil {
+		if _, err := s.budgetRepository.Get(*budgetID, userID); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			refErr["BudgetID"] = "exists"
+		}
+	}
+	if len(refErr) > 0 {
+		return refErr
+	}
+	return nil
+}
+
+func newPayeeResponse(payee models.Payee) models.PayeeResponse {
+	return models.PayeeResponse{
+		ID:         payee.ID,
+		Title:      payee.Title,
+		CategoryID: models.IDFromNull(payee.CategoryID),
+		BudgetID:   models.IDFromNull(payee.BudgetID),
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"finapp/models"
+	"finapp/repository"
+)
+
+// Нарушения ссылок запроса на бюджеты и цели: поле -> правило,
+// в том же виде, что и ошибки валидации запроса. Отдаётся клиенту как 422
+type ReferenceError map[string]string
+
+func (e ReferenceError) Error() string {
+	fields := make([]string, 0, len(e))
+	for field, rule := range e {
+		fields = append(fields, field+": "+rule)
+	}
+	sort.Strings(fields)
+	return "invalid references: " + strings.Join(fields, ", ")
+}
+
+// Добавляет нарушения другой ошибки с префиксом пути к полю
+func (e ReferenceError) merge(prefix string, err ReferenceError) {
+	for field, rule := range err {
+		e[prefix+field] = rule
+	}
+}
+
+// Проверяет бюджеты перевода: задан хотя бы один, они различны,
+// принадлежат пользователю и не удалены
+func checkTransfer(budgets repository.BudgetRepository, userID uint, from, to *uint) error {
+	refErr := make(ReferenceError)
+	switch {
+	case from == nil && to == nil:
+		refErr["BudgetFrom"] = "required_without=BudgetTo"
+		refErr["BudgetTo"] = "required_without=BudgetFrom"
+		return refErr
+	case from != nil && to != nil && *from == *to:
+		refErr["BudgetTo"] = "nefield=BudgetFrom"
+		return refErr
+	}
+
+	for field, id := range map[string]*uint{"BudgetFrom": from, "BudgetTo": to} {
+		if id == nil {
+			continue
+		}
+		role, err := budgets.Role(*id, userID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			refErr[field] = "exists"
+			continue
+		}
+		// Наблюдатель общего бюджета не может проводить через него транзакции
+		if !slices.Contains(models.BudgetWriteRoles, role) {
+			refErr[field] = "editable"
+		}
+	}
+	if len(refErr) > 0 {
+		return refErr
+	}
+	return nil
+}
+
+// Проверяет, что цель принадлежит пользователю и не удалена
+func checkGoal(goals repository.GoalRepository, userID uint, goalID *uint) error {
+	if goalID == nil {
+		return nil
+	}
+	if _, err := goals.Get(*goalID, userID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return ReferenceError{"Goal": "exists"}
+	}
+	return nil
+}
+
+// Превращает отсутствие записи, на которую ссылается поле, в нарушение ссылки
+func checkExists(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ReferenceError{field: "exists"}
+	}
+	return err
+}
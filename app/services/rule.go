@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+type RuleService struct {
+	logger             lib.Logger
+	repository         repository.RuleRepository
+	payeeRepository    repository.PayeeRepository
+	categoryRepository repository.CategoryRepository
+	budgetRepository   repository.BudgetRepository
+	tagRepository      repository.TagRepository
+	trxRepository      repository.TrxRepository
+}
+
+func NewRuleService(
+	logger lib.Logger,
+	repository repository.RuleRepository,
+	payeeRepository repository.PayeeRepository,
+	categoryRepository repository.CategoryRepository,
+	budgetRepository repository.BudgetRepository,
+	tagRepository repository.TagRepository,
+	trxRepository repository.TrxRepository,
+) domains.RuleService {
+	return RuleService{
+		logger:             logger,
+		repository:         repository,
+		payeeRepository:    payeeRepository,
+		categoryRepository: categoryRepository,
+		budgetRepository:   budgetRepository,
+		tagRepository:      tagRepository,
+		trxRepository:      trxRepository,
+	}
+}
+
+func (s RuleService) WithTrx(trxHandle *gorm.DB) domains.RuleService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	s.payeeRepository = s.payeeRepository.WithTrx(trxHandle)
+	s.categoryRepository = s.categoryRepository.WithTrx(trxHandle)
+	s.budgetRepository = s.budgetRepository.WithTrx(trxHandle)
+	s.tagRepository = s.tagRepository.WithTrx(trxHandle)
+	s.trxRepository = s.trxRepository.WithTrx(trxHandle)
+	return s
+}
+
+func (s RuleService) List(userID uint) ([]models.RuleResponse, error) {
+	rules, err := s.repository.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []models.RuleResponse
+	for _, v := range rules {
+		resp = append(resp, newRuleResponse(v))
+	}
+	return resp, nil
+}
+
+func (s RuleService) Get(c *gin.Context, userID uint) (models.RuleResponse, error) {
+	id, err := ruleID(c)
+	if err != nil {
+		return models.RuleResponse{}, err
+	}
+
+	rule, err := s.repository.Get(id, userID)
+	if err != nil {
+		return models.RuleResponse{}, err
+	}
+	return newRuleResponse(rule), nil
+}
+
+func (s RuleService) Store(request *models.RuleRequest, userID uint) (models.RuleResponse, error) {
+	rule := models.Rule{UserID: userID}
+	if err := s.fill(&rule, request, userID); err != nil {
+		return models.RuleResponse{}, err
+	}
+
+	if err := s.repository.Create(&rule); err != nil {
+		return models.RuleResponse{}, err
+	}
+	return newRuleResponse(rule), nil
+}
+
+func (s RuleService) Replace(c *gin.Context, request *models.RuleRequest, userID uint) (models.RuleResponse, error) {
+	id, err := ruleID(c)
+	if err != nil {
+		return models.RuleResponse{}, err
+	}
+
+	rule, err := s.repository.Get(id, userID)
+	if err != nil {
+		return models.RuleResponse{}, err
+	}
+	if err := s.fill(&rule, request, userID); err != nil {
+		return models.RuleResponse{}, err
+	}
+
+	if err := s.repository.Replace(&rule); err != nil {
+		return models.RuleResponse{}, err
+	}
+	return newRuleResponse(rule), nil
+}
+
+func (s RuleService) Delete(c *gin.Context, userID uint) error {
+	id, err := ruleID(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.repository.Get(id, userID); err != nil {
+		return err
+	}
+	return s.repository.Delete(id, userID)
+}
+
+// Применяет правила к уже существующим транзакциям, отобранным
+// теми же фильтрами, что и список транзакций
+func (s RuleService) Apply(c *gin.Context, request models.RuleApplyRequest, userID uint) (models.RuleApplyResponse, error) {
+	filter, err := parseTrxFilter(c)
+	if err != nil {
+		return models.RuleApplyResponse{}, err
+	}
+
+	rules, err := s.repository.List(userID)
+	if err != nil {
+		return models.RuleApplyResponse{}, err
+	}
+	payees, err := s.payeeRepository.List(userID)
+	if err != nil {
+		return models.RuleApplyResponse{}, err
+	}
+	set := newRuleSet(rules, payees)
+
+	var resp models.RuleApplyResponse
+	err = s.trxRepository.Each(userID, filter, func(trx models.Trx) error {
+		// Правила личные и не трогают транзакции других участников общих бюджетов
+		if trx.UserID != userID || isReconciled(trx) {
+			return nil
+		}
+		before := trx
+		before.Tags = append([]models.Tag(nil), trx.Tags...)
+
+		matched, changed := set.Apply(&trx, request.Overwrite)
+		if matched {
+			resp.Matched++
+		}
+		if !changed {
+			return nil
+		}
+		resp.Updated++
+
+		updates := make(map[string]any)
+		if !sameID(before.PayeeID, trx.PayeeID) {
+			updates["payee_id"] = trx.PayeeID
+		}
+		if !sameID(before.CategoryID, trx.CategoryID) {
+			updates["category_id"] = trx.CategoryID
+		}
+		if !sameID(before.BudgetFrom, trx.BudgetFrom) {
+			updates["budget_from"] = trx.BudgetFrom
+		}
+		if !sameID(before.BudgetTo, trx.BudgetTo) {
+			updates["budget_to"] = trx.BudgetTo
+		}
+		if _, err := s.trxRepository.Patch(updates, trx.ID, userID); err != nil {
+			return err
+		}
+		if len(trx.Tags) != len(before.Tags) {
+			return s.trxRepository.ReplaceTags(&trx, trx.Tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.RuleApplyResponse{}, err
+	}
+	return resp, nil
+}
+
+// Переносит запрос в правило, проверяя шаблон, диапазон суммы
+// и принадлежность пользователю получателя, категории и бюджета
+func (s RuleService) fill(rule *models.Rule, request *models.RuleRequest, userID uint) error {
+	match := request.Match
+	if match == "" {
+		match = models.RuleMatchContains
+	}
+	pattern := strings.TrimSpace(request.Pattern)
+
+	rule.Title = request.Title
+	rule.Priority = request.Priority
+	rule.Match = match
+	rule.Pattern = pattern
+	rule.MinAmount = request.MinAmount
+	rule.MaxAmount = request.MaxAmount
+	rule.PayeeID = models.NullID(request.PayeeID)
+	rule.CategoryID = models.NullID(request.CategoryID)
+	rule.BudgetID = models.NullID(request.BudgetID)
+	rule.BudgetField = ""
+	if request.BudgetID != nil {
+		rule.BudgetField = request.BudgetField
+		if rule.BudgetField == "" {
+			rule.BudgetField = "budget_from"
+		}
+	}
+
+	if _, err := compileRule(*rule); err != nil {
+		return errors.New("invalid rule pattern: " + err.Error())
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && rule.MinAmount.GreaterThan(*rule.MaxAmount) {
+		return errors.New("min_amount goes after max_amount")
+	}
+
+	refErr := make(ReferenceError)
+	if request.PayeeID != nil {
+		if _, err := s.payeeRepository.Get(*request.PayeeID, userID); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			refErr["PayeeID"] = "exists"
+		}
+	}
+	if request.CategoryID != nil {
+		if _, err := s.categoryRepository.Get(*request.CategoryID, userID); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			refErr["CategoryID"] = "exists"
+		}
+	}
+	if request.BudgetID != nil {
+		if _, err := s.budgetRepository.Get(*request.BudgetID, userID); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			refErr["BudgetID"] = "exists"
+		}
+	}
+	if len(refErr) > 0 {
+		return refErr
+	}
+
+	tags, err := s.tagRepository.GetOrCreate(normalizeTags(request.Tags), userID)
+	if err != nil {
+		return err
+	}
+	rule.Tags = tags
+	return nil
+}
+
+func ruleID(c *gin.Context) (uint, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return 0, errors.New("rule id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+func newRuleResponse(rule models.Rule) models.RuleResponse {
+	return models.RuleResponse{
+		ID:          rule.ID,
+		Title:       rule.Title,
+		Priority:    rule.Priority,
+		Match:       rule.Match,
+		Pattern:     rule.Pattern,
+		MinAmount:   rule.MinAmount,
+		MaxAmount:   rule.MaxAmount,
+		PayeeID:     models.IDFromNull(rule.PayeeID),
+		CategoryID:  models.IDFromNull(rule.CategoryID),
+		Tags:        convertTagsToTitles(rule.Tags),
+		BudgetID:    models.IDFromNull(rule.BudgetID),
+		BudgetField: rule.BudgetField,
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"finapp/models"
+)
+
+// Правило с подготовленным шаблоном названия
+type compiledRule struct {
+	models.Rule
+	re *regexp.Regexp
+}
+
+func compileRule(rule models.Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule}
+	if rule.Match == models.RuleMatchRegex && rule.Pattern != "" {
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		compiled.re = re
+	}
+	return compiled, nil
+}
+
+func (r compiledRule) Matches(trx models.Trx) bool {
+	if r.Pattern != "" {
+		if r.re != nil {
+			if !r.re.MatchString(trx.Title) {
+				return false
+			}
+		} else if !strings.Contains(strings.ToLower(trx.Title), strings.ToLower(r.Pattern)) {
+			return false
+		}
+	}
+	if r.MinAmount != nil && trx.Amount.LessThan(*r.MinAmount) {
+		return false
+	}
+	if r.MaxAmount != nil && trx.Amount.GreaterThan(*r.MaxAmount) {
+		return false
+	}
+	return true
+}
+
+// Набор правил пользователя и его получателей для категоризации транзакций
+type ruleSet struct {
+	rules  []compiledRule
+	payees map[uint]models.Payee
+}
+
+func newRuleSet(rules []models.Rule, payees []models.Payee) ruleSet {
+	set := ruleSet{payees: make(map[uint]models.Payee, len(payees))}
+	for _, rule := range rules {
+		// Шаблон проверяется при сохранении правила, поэтому ошибка здесь
+		// означает устаревшее правило, которое просто пропускается
+		if compiled, err := compileRule(rule); err == nil {
+			set.rules = append(set.rules, compiled)
+		}
+	}
+	for _, payee := range payees {
+		set.payees[payee.ID] = payee
+	}
+	return set
+}
+
+// Применяет правила к транзакции. Для каждого поля побеждает первое подошедшее
+// правило, уже заданные поля меняются только при overwrite. Бюджет назначается
+// только транзакции без бюджетов, чтобы не превратить доход или расход в перевод.
+// Получатель дополняет пустые категорию и бюджет своими значениями по умолчанию.
+// Возвращает, подошло ли хоть одно правило и изменилась ли транзакция
+func (s ruleSet) Apply(trx *models.Trx, overwrite bool) (matched, changed bool) {
+	canSet := func(field *sql.NullInt64) bool {
+		return overwrite || field == nil || !field.Valid
+	}
+	canSetBudget := !trx.IsSplit && trx.ParentID == nil &&
+		!isValidID(trx.BudgetFrom) && !isValidID(trx.BudgetTo)
+
+	var payeeSet, categorySet, budgetSet bool
+	for _, rule := range s.rules {
+		if !rule.Matches(*trx) {
+			continue
+		}
+		matched = true
+
+		if !payeeSet && isValidID(rule.PayeeID) && canSet(trx.PayeeID) {
+			changed = setID(&trx.PayeeID, rule.PayeeID) || changed
+			payeeSet = true
+		}
+		if !categorySet && isValidID(rule.CategoryID) && canSet(trx.CategoryID) {
+			changed = setID(&trx.CategoryID, rule.CategoryID) || changed
+			categorySet = true
+		}
+		if !budgetSet && canSetBudget && isValidID(rule.BudgetID) {
+			if rule.BudgetField == "budget_to" {
+				trx.BudgetTo = rule.BudgetID
+			} else {
+				trx.BudgetFrom = rule.BudgetID
+			}
+			changed, budgetSet = true, true
+		}
+		for _, tag := range rule.Tags {
+			if !hasTag(trx.Tags, tag.ID) {
+				trx.Tags = append(trx.Tags, tag)
+				changed = true
+			}
+		}
+	}
+
+	if !isValidID(trx.PayeeID) {
+		return matched, changed
+	}
+	payee, ok := s.payees[uint(trx.PayeeID.Int64)]
+	if !ok {
+		return matched, changed
+	}
+	if !categorySet && isValidID(payee.CategoryID) && !isValidID(trx.CategoryID) {
+		changed = setID(&trx.CategoryID, payee.CategoryID) || changed
+	}
+	if !budgetSet && canSetBudget && isValidID(payee.BudgetID) {
+		trx.BudgetFrom = payee.BudgetID
+		changed = true
+	}
+	return matched, changed
+}
+
+func isValidID(id *sql.NullInt64) bool {
+	return id != nil && id.Valid
+}
+
+func setID(field **sql.NullInt64, value *sql.NullInt64) bool {
+	if isValidID(*field) && (*field).Int64 == value.Int64 {
+		return false
+	}
+	*field = &sql.NullInt64{Int64: value.Int64, Valid: true}
+	return true
+}
+
+func hasTag(tags []models.Tag, id uint) bool {
+	for _, tag := range tags {
+		if tag.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func sameID(a, b *sql.NullInt64) bool {
+	if !isValidID(a) || !isValidID(b) {
+		return isValidID(a) == isValidID(b)
+	}
+	return a.Int64 == b.Int64
+}
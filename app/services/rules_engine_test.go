@@ -0,0 +1,160 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"finapp/models"
+)
+
+func validID(id int64) *sql.NullInt64 {
+	return &sql.NullInt64{Int64: id, Valid: true}
+}
+
+func amountPtr(value string) *decimal.Decimal {
+	amount := decimal.RequireFromString(value)
+	return &amount
+}
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   models.Rule
+		title  string
+		amount string
+		want   bool
+	}{
+		{"contains ignores case", models.Rule{Pattern: "кофе"}, "КОФЕ Хауз", "100", true},
+		{"contains misses", models.Rule{Pattern: "taxi"}, "Coffee", "100", false},
+		{"regex", models.Rule{Match: models.RuleMatchRegex, Pattern: `^uber\s+\d+`}, "Uber 123", "100", true},
+		{"regex misses", models.Rule{Match: models.RuleMatchRegex, Pattern: `^uber$`}, "Uber Eats", "100", false},
+		{"pattern is literal for contains", models.Rule{Pattern: "a.c"}, "abc", "100", false},
+		{"min amount inclusive", models.Rule{MinAmount: amountPtr("100.00")}, "x", "100", true},
+		{"below min amount", models.Rule{MinAmount: amountPtr("100")}, "x", "99.99", false},
+		{"max amount inclusive", models.Rule{MaxAmount: amountPtr("50")}, "x", "50.00", true},
+		{"above max amount", models.Rule{MaxAmount: amountPtr("50")}, "x", "50.01", false},
+		{"pattern and range", models.Rule{Pattern: "shop", MinAmount: amountPtr("-10"), MaxAmount: amountPtr("10")}, "Shop", "-5", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := compileRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			trx := models.Trx{Title: tt.title, Amount: decimal.RequireFromString(tt.amount)}
+			if got := rule.Matches(trx); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleSetApply(t *testing.T) {
+	rules := []models.Rule{
+		{Match: models.RuleMatchRegex, Pattern: "(broken"},
+		{Pattern: "coffee", CategoryID: validID(1), Tags: []models.Tag{{Model: gorm.Model{ID: 7}}}},
+		{Pattern: "coffee", CategoryID: validID(2), PayeeID: validID(3), Tags: []models.Tag{{Model: gorm.Model{ID: 7}}}},
+		{Pattern: "salary", BudgetID: validID(5), BudgetField: "budget_to"},
+		{Pattern: "market", PayeeID: validID(4)},
+	}
+	payees := []models.Payee{
+		{Model: gorm.Model{ID: 3}, CategoryID: validID(9)},
+		{Model: gorm.Model{ID: 4}, CategoryID: validID(8), BudgetID: validID(6)},
+	}
+	set := newRuleSet(rules, payees)
+
+	tests := []struct {
+		name        string
+		trx         models.Trx
+		overwrite   bool
+		wantMatched bool
+		wantChanged bool
+		// Ожидаемые поля в виде "payee/category/from/to/tags"
+		want string
+	}{
+		{
+			name:        "first rule wins per field",
+			trx:         models.Trx{Title: "Coffee", BudgetFrom: validID(1)},
+			wantMatched: true, wantChanged: true,
+			want: "3/1/1/-/[7]",
+		},
+		{
+			name:        "existing category kept without overwrite",
+			trx:         models.Trx{Title: "Coffee", BudgetFrom: validID(1), CategoryID: validID(10), PayeeID: validID(3)},
+			wantMatched: true, wantChanged: true,
+			want: "3/10/1/-/[7]",
+		},
+		{
+			name:        "overwrite replaces category",
+			trx:         models.Trx{Title: "Coffee", BudgetFrom: validID(1), CategoryID: validID(10), Tags: []models.Tag{{Model: gorm.Model{ID: 7}}}},
+			overwrite:   true,
+			wantMatched: true, wantChanged: true,
+			want: "3/1/1/-/[7]",
+		},
+		{
+			name:        "nothing to change",
+			trx:         models.Trx{Title: "Coffee", BudgetFrom: validID(1), CategoryID: validID(1), PayeeID: validID(3), Tags: []models.Tag{{Model: gorm.Model{ID: 7}}}},
+			wantMatched: true,
+			want:        "3/1/1/-/[7]",
+		},
+		{
+			name:        "budget set on trx without budgets",
+			trx:         models.Trx{Title: "Salary"},
+			wantMatched: true, wantChanged: true,
+			want: "-/-/-/5/[]",
+		},
+		{
+			name:        "budget kept on trx with budget",
+			trx:         models.Trx{Title: "Salary", BudgetFrom: validID(2)},
+			wantMatched: true,
+			want:        "-/-/2/-/[]",
+		},
+		{
+			name:        "payee defaults fill category and budget",
+			trx:         models.Trx{Title: "Market"},
+			wantMatched: true, wantChanged: true,
+			want: "4/8/6/-/[]",
+		},
+		{
+			name:        "split trx gets no budget",
+			trx:         models.Trx{Title: "Market", IsSplit: true},
+			wantMatched: true, wantChanged: true,
+			want: "4/8/-/-/[]",
+		},
+		{
+			name: "no rule matches",
+			trx:  models.Trx{Title: "Rent", BudgetFrom: validID(1)},
+			want: "-/-/1/-/[]",
+		},
+	}
+
+	idOf := func(id *sql.NullInt64) string {
+		if !isValidID(id) {
+			return "-"
+		}
+		return fmt.Sprint(id.Int64)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trx := tt.trx
+			matched, changed := set.Apply(&trx, tt.overwrite)
+			if matched != tt.wantMatched || changed != tt.wantChanged {
+				t.Fatalf("matched, changed = %v, %v, want %v, %v", matched, changed, tt.wantMatched, tt.wantChanged)
+			}
+			tags := make([]uint, 0, len(trx.Tags))
+			for _, tag := range trx.Tags {
+				tags = append(tags, tag.ID)
+			}
+			got := fmt.Sprintf("%s/%s/%s/%s/%v", idOf(trx.PayeeID), idOf(trx.CategoryID), idOf(trx.BudgetFrom), idOf(trx.BudgetTo), tags)
+			if got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,4 +10,13 @@ var Module = fx.Options(
 	fx.Provide(NewBudgetService),
 	fx.Provide(NewGoalService),
 	fx.Provide(NewGeneratorService),
+	fx.Provide(NewCategoryService),
+	fx.Provide(NewTagService),
+	fx.Provide(NewExchangeRateService),
+	fx.Provide(NewAttachmentService),
+	fx.Provide(NewPayeeService),
+	fx.Provide(NewRuleService),
+	fx.Provide(NewTrashService),
+	fx.Provide(NewHistoryService),
+	fx.Provide(NewBudgetMemberService),
 )
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/models"
+	"finapp/repository"
+)
+
+type TagService struct {
+	logger     lib.Logger
+	repository repository.TagRepository
+}
+
+func NewTagService(
+	logger lib.Logger,
+	repository repository.TagRepository,
+) domains.TagService {
+	return TagService{
+		logger:     logger,
+		repository: repository,
+	}
+}
+
+func (s TagService) List(userID uint) ([]models.TagResponse, error) {
+	tags, err := s.repository.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []models.TagResponse
+	for _, v := range tags {
+		resp = append(resp, models.TagResponse{
+			ID:    v.ID,
+			Title: v.Title,
+		})
+	}
+	return resp, nil
+}
+
+func (s TagService) Rename(c *gin.Context, request models.TagRenameRequest, userID uint) (models.TagResponse, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return models.TagResponse{}, errors.New("tag id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return models.TagResponse{}, err
+	}
+
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return models.TagResponse{}, errors.New("tag title is empty")
+	}
+
+	if _, err := s.repository.Get(uint(id), userID); err != nil {
+		return models.TagResponse{}, err
+	}
+
+	existing, err := s.repository.GetByTitle(title, userID)
+	if err == nil && existing.ID != uint(id) {
+		return models.TagResponse{}, errors.New("tag with this title already exists, merge tags instead")
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.TagResponse{}, err
+	}
+
+	tag, err := s.repository.Rename(uint(id), userID, title)
+	if err != nil {
+		return models.TagResponse{}, err
+	}
+
+	resp := models.TagResponse{
+		ID:    tag.ID,
+		Title: tag.Title,
+	}
+	return resp, nil
+}
+
+func (s TagService) Merge(c *gin.Context, request models.TagMergeRequest, userID uint) (models.TagResponse, error) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return models.TagResponse{}, errors.New("tag id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return models.TagResponse{}, err
+	}
+
+	if uint(id) == request.TargetID {
+		return models.TagResponse{}, errors.New("tag can't be merged into itself")
+	}
+
+	if _, err := s.repository.Get(uint(id), userID); err != nil {
+		return models.TagResponse{}, err
+	}
+	target, err := s.repository.Get(request.TargetID, userID)
+	if err != nil {
+		return models.TagResponse{}, err
+	}
+
+	if err := s.repository.Merge(uint(id), target.ID, userID); err != nil {
+		return models.TagResponse{}, err
+	}
+
+	resp := models.TagResponse{
+		ID:    target.ID,
+		Title: target.Title,
+	}
+	return resp, nil
+}
+
+func (s TagService) Delete(c *gin.Context, userID uint) error {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return errors.New("tag id does not exists")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.repository.Get(uint(id), userID); err != nil {
+		return err
+	}
+
+	return s.repository.Delete(uint(id), userID)
+}
+
+// Убирает пробелы по краям, пустые значения и повторы
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	return res
+}
+
+func convertTagsToTitles(tags []models.Tag) []string {
+	titles := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		titles = append(titles, tag.Title)
+	}
+	return titles
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"finapp/constants"
+	"finapp/domains"
+	"finapp/lib"
+	"finapp/lib/storage"
+	"finapp/models"
+	"finapp/repository"
+)
+
+// Бюджет в корзине, на который ещё ссылаются транзакции или генераторы
+var ErrBudgetInUse = repository.ErrBudgetInUse
+
+type TrashService struct {
+	logger     lib.Logger
+	repository repository.TrashRepository
+	storage    storage.Storage
+	retention  int
+}
+
+func NewTrashService(
+	logger lib.Logger,
+	env lib.Env,
+	repository repository.TrashRepository,
+	storage storage.Storage,
+) domains.TrashService {
+	return TrashService{
+		logger:     logger,
+		repository: repository,
+		storage:    storage,
+		retention:  env.TrashRetentionDays,
+	}
+}
+
+func (s TrashService) WithTrx(trxHandle *gorm.DB) domains.TrashService {
+	s.repository = s.repository.WithTrx(trxHandle)
+	return s
+}
+
+// Содержимое корзины, сначала недавно удалённые записи.
+// entity ограничивает список одним типом записей
+func (s TrashService) List(c *gin.Context, userID uint) ([]models.TrashItemResponse, error) {
+	entities := []models.TrashEntity{models.TrashTrx, models.TrashBudget, models.TrashGoal, models.TrashGenerator}
+	if entity := c.Query("entity"); entity != "" {
+		entities = []models.TrashEntity{models.TrashEntity(entity)}
+	}
+
+	var records []models.TrashRecord
+	for _, entity := range entities {
+		entityRecords, err := s.repository.List(userID, entity)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, entityRecords...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DeletedAt.After(records[j].DeletedAt)
+	})
+
+	resp := make([]models.TrashItemResponse, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, models.TrashItemResponse{
+			Entity:    record.Entity,
+			ID:        record.ID,
+			Title:     record.Title,
+			DeletedAt: record.DeletedAt.Format(constants.DateFormat),
+			PurgeAt:   record.DeletedAt.AddDate(0, 0, s.retention).Format(constants.DateFormat),
+		})
+	}
+	return resp, nil
+}
+
+func (s TrashService) Restore(c *gin.Context, userID uint) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return s.repository.Restore(models.TrashEntity(c.Param("entity")), uint(id), userID)
+}
+
+func (s TrashService) Purge(c *gin.Context, userID uint) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.repository.Purge(models.TrashEntity(c.Param("entity")), uint(id), userID)
+	if err != nil {
+		return err
+	}
+	lib.AfterCommit(c, func() { s.DeleteFiles(keys) })
+	return nil
+}
+
+// Окончательно удаляет всё содержимое корзины пользователя
+func (s TrashService) Empty(c *gin.Context, userID uint) (models.TrashPurgeResponse, error) {
+	purged, keys, err := s.repository.PurgeUser(userID)
+	if err != nil {
+		return models.TrashPurgeResponse{}, err
+	}
+	lib.AfterCommit(c, func() { s.DeleteFiles(keys) })
+	return models.TrashPurgeResponse{Purged: purged}, nil
+}
+
+// Окончательно удаляет записи всех пользователей, пролежавшие в корзине дольше срока хранения.
+// Возвращает ключи файлов вложений, которые нужно удалить после фиксации транзакции
+func (s TrashService) PurgeExpired(before time.Time) (models.TrashPurgeResponse, []string, error) {
+	purged, keys, err := s.repository.PurgeBefore(before)
+	if err != nil {
+		return models.TrashPurgeResponse{}, nil, err
+	}
+	return models.TrashPurgeResponse{Purged: purged}, keys, nil
+}
+
+// Удаляет файлы вложений из хранилища. Вызывается только после фиксации
+// транзакции, иначе при откате вложения останутся без файлов.
+// Оставшийся в хранилище файл не мешает работе, поэтому ошибка только логируется
+func (s TrashService) DeleteFiles(keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(key); err != nil {
+			s.logger.Error("failed to delete attachment file: ", err)
+		}
+	}
+}
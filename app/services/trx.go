@@ -2,8 +2,12 @@ package services
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,91 +17,103 @@ import (
 	"finapp/constants"
 	"finapp/domains"
 	"finapp/lib"
+	"finapp/lib/export"
+	"finapp/lib/validators"
 	"finapp/models"
 	"finapp/repository"
 )
 
+// Сверенная транзакция изменяется только после разблокировки
+var ErrTrxReconciled = errors.New("trx is reconciled, unlock it first")
+
 type TrxService struct {
-	logger           lib.Logger
-	repository       repository.TrxRepository
-	budgetRepository repository.BudgetRepository
+	logger               lib.Logger
+	repository           repository.TrxRepository
+	budgetRepository     repository.BudgetRepository
+	categoryRepository   repository.CategoryRepository
+	tagRepository        repository.TagRepository
+	rateRepository       repository.ExchangeRateRepository
+	attachmentRepository repository.AttachmentRepository
+	payeeRepository      repository.PayeeRepository
+	ruleRepository       repository.RuleRepository
 }
 
 func NewTrxService(
 	logger lib.Logger,
 	repository repository.TrxRepository,
 	budgetRepository repository.BudgetRepository,
+	categoryRepository repository.CategoryRepository,
+	tagRepository repository.TagRepository,
+	rateRepository repository.ExchangeRateRepository,
+	attachmentRepository repository.AttachmentRepository,
+	payeeRepository repository.PayeeRepository,
+	ruleRepository repository.RuleRepository,
 ) domains.TrxService {
 	return TrxService{
-		logger:           logger,
-		repository:       repository,
-		budgetRepository: budgetRepository,
+		logger:               logger,
+		repository:           repository,
+		budgetRepository:     budgetRepository,
+		categoryRepository:   categoryRepository,
+		tagRepository:        tagRepository,
+		rateRepository:       rateRepository,
+		attachmentRepository: attachmentRepository,
+		payeeRepository:      payeeRepository,
+		ruleRepository:       ruleRepository,
 	}
 }
 
 func (s TrxService) WithTrx(trxHandle *gorm.DB) domains.TrxService {
 	s.repository = s.repository.WithTrx(trxHandle)
+	s.budgetRepository = s.budgetRepository.WithTrx(trxHandle)
+	s.categoryRepository = s.categoryRepository.WithTrx(trxHandle)
+	s.tagRepository = s.tagRepository.WithTrx(trxHandle)
+	s.rateRepository = s.rateRepository.WithTrx(trxHandle)
+	s.attachmentRepository = s.attachmentRepository.WithTrx(trxHandle)
+	s.payeeRepository = s.payeeRepository.WithTrx(trxHandle)
+	s.ruleRepository = s.ruleRepository.WithTrx(trxHandle)
 	return s
 }
 
-func (s TrxService) List(c *gin.Context, userID uint) ([]models.TrxResponse, error) {
-	var (
-		dateFrom  time.Time
-		dateTo    time.Time
-		minAmount decimal.Decimal
-		maxAmount decimal.Decimal
-	)
+// Поля сортировки списка транзакций
+var trxSorts = map[string]sortField{
+	"date":       {Column: "date", Kind: sortKindTime},
+	"amount":     {Column: "CAST(amount AS DECIMAL)", Kind: sortKindDecimal},
+	"created_at": {Column: "created_at", Kind: sortKindTime},
+}
 
-	if amountMinStr := c.Query("amount_min"); amountMinStr != "" {
-		amountMin, err := decimal.NewFromString(amountMinStr)
-		if err != nil {
-			return nil, err
-		}
-		minAmount = amountMin
-	}
-	if amountMaxStr := c.Query("amount_max"); amountMaxStr != "" {
-		amountMax, err := decimal.NewFromString(amountMaxStr)
-		if err != nil {
-			return nil, err
-		}
-		maxAmount = amountMax
-	}
+// При поиске дополнительно доступна сортировка по рангу,
+// выражение ранга подставляет репозиторий
+var trxSearchSorts = map[string]sortField{
+	"date":               trxSorts["date"],
+	"amount":             trxSorts["amount"],
+	"created_at":         trxSorts["created_at"],
+	models.SortRelevance: {Kind: sortKindDecimal},
+}
 
-	if dateFromStr := c.Query("date_from"); dateFromStr != "" {
-		dateFromTemp, err := time.Parse(constants.DateFormat, dateFromStr)
-		if err != nil {
-			return nil, err
-		}
-		dateFrom = dateFromTemp
+func (s TrxService) List(c *gin.Context, userID uint) (models.PageResponse[models.TrxResponse], error) {
+	filter, err := parseTrxFilter(c)
+	if err != nil {
+		return models.PageResponse[models.TrxResponse]{}, err
 	}
 
-	if dateToStr := c.Query("date_to"); dateToStr != "" {
-		dateToTemp, err := time.Parse(constants.DateFormat, dateToStr)
-		if err != nil {
-			return nil, err
-		}
-		dateTo = dateToTemp
+	sorts, defaultSort := trxSorts, "date"
+	if filter.Query != "" {
+		sorts, defaultSort = trxSearchSorts, models.SortRelevance
 	}
-
-	// Выполнение запроса
-	trxs, err := s.repository.List(userID, dateFrom, dateTo, minAmount, maxAmount)
+	page, err := parsePageRequest(c, sorts, defaultSort)
 	if err != nil {
-		return nil, err
+		return models.PageResponse[models.TrxResponse]{}, err
 	}
 
-	var trxResponses []models.TrxResponse
-	for _, trx := range trxs {
-		trxResponses = append(trxResponses, models.TrxResponse{
-			ID:         trx.ID,
-			Title:      trx.Title,
-			Date:       trx.Date.Format(constants.DateFormat),
-			Amount:     trx.Amount.InexactFloat64(),
-			BudgetFrom: convertBudgetID(trx.BudgetFrom),
-			BudgetTo:   convertBudgetID(trx.BudgetTo),
-		})
+	// Выполнение запроса
+	trxs, err := s.repository.List(userID, filter, page)
+	if err != nil {
+		return models.PageResponse[models.TrxResponse]{}, err
 	}
 
-	return trxResponses, nil
+	return newPageResponse(trxs, page, func(trx models.Trx) (any, uint) {
+		return trxSortValue(trx, page.Sort), trx.ID
+	}, newTrxResponse), nil
 }
 
 func (s TrxService) Get(c *gin.Context, userID uint) (models.TrxResponse, error) {
@@ -112,16 +128,7 @@ func (s TrxService) Get(c *gin.Context, userID uint) (models.TrxResponse, error)
 		return models.TrxResponse{}, err
 	}
 
-	resp := models.TrxResponse{
-		ID:         trx.ID,
-		Title:      trx.Title,
-		Date:       trx.Date.Format(constants.DateFormat),
-		Amount:     trx.Amount.InexactFloat64(),
-		BudgetFrom: convertBudgetID(trx.BudgetFrom),
-		BudgetTo:   convertBudgetID(trx.BudgetTo),
-	}
-
-	return resp, nil
+	return newTrxResponse(trx), nil
 }
 
 func (s TrxService) Create(trxRequest *models.TrxRequest, userID uint) (models.TrxResponse, error) {
@@ -130,11 +137,18 @@ func (s TrxService) Create(trxRequest *models.TrxRequest, userID uint) (models.T
 		return models.TrxResponse{}, err
 	}
 
-	amount := decimal.NewFromFloat(trxRequest.Amount)
+	amount := trxRequest.Amount
+
+	if trxRequest.CategoryID != nil {
+		if _, err := s.categoryRepository.Get(*trxRequest.CategoryID, userID); err != nil {
+			return models.TrxResponse{}, checkExists("CategoryID", err)
+		}
+	}
 
 	transaction := models.Trx{
 		UserID: userID,
 		Title:  trxRequest.Title,
+		Note:   trxRequest.Note,
 		Date:   date,
 		Amount: amount,
 		BudgetTo: func() *sql.NullInt64 {
@@ -155,22 +169,72 @@ func (s TrxService) Create(trxRequest *models.TrxRequest, userID uint) (models.T
 			}
 			return &sql.NullInt64{}
 		}(),
+		CategoryID: models.NullID(trxRequest.CategoryID),
+		PayeeID:    models.NullID(trxRequest.PayeeID),
+		Status:     trxRequest.Status,
+		IsSplit:    len(trxRequest.Legs) > 0,
+	}
+	if transaction.Status == "" {
+		transaction.Status = models.TrxStatusCleared
 	}
 
-	err = s.repository.Create(&transaction)
+	if trxRequest.PayeeID != nil {
+		if _, err := s.payeeRepository.Get(*trxRequest.PayeeID, userID); err != nil {
+			return models.TrxResponse{}, checkExists("PayeeID", err)
+		}
+	}
+
+	if tags := normalizeTags(trxRequest.Tags); len(tags) > 0 {
+		transaction.Tags, err = s.tagRepository.GetOrCreate(tags, userID)
+		if err != nil {
+			return models.TrxResponse{}, err
+		}
+	}
+
+	// Правила дополняют то, что не задано в запросе, в том числе бюджет
+	rules, err := s.loadRules(userID)
 	if err != nil {
 		return models.TrxResponse{}, err
 	}
+	rules.Apply(&transaction, false)
+
+	if transaction.IsSplit {
+		if trxRequest.BudgetFrom != nil || trxRequest.BudgetTo != nil {
+			return models.TrxResponse{}, errors.New("budgets of split trx are set in legs")
+		}
+		if err := s.checkLegs(userID, trxRequest.Legs); err != nil {
+			return models.TrxResponse{}, err
+		}
+		transaction.Legs, err = buildTrxLegs(transaction, trxRequest.Legs)
+		if err != nil {
+			return models.TrxResponse{}, err
+		}
+		if err := s.setLegsCurrency(&transaction, trxRequest.Currency); err != nil {
+			return models.TrxResponse{}, err
+		}
+	} else if err := checkTransfer(s.budgetRepository, userID,
+		models.IDFromNull(transaction.BudgetFrom), models.IDFromNull(transaction.BudgetTo)); err != nil {
+		return models.TrxResponse{}, err
+	} else if err := s.setCurrency(&transaction, trxRequest.Currency, trxRequest.DestAmount); err != nil {
+		return models.TrxResponse{}, err
+	}
+
+	if trxRequest.CheckDuplicates && !transaction.IsSplit {
+		duplicates, err := s.findDuplicates(transaction)
+		if err != nil {
+			return models.TrxResponse{}, err
+		}
+		if len(duplicates) > 0 {
+			return models.TrxResponse{}, DuplicateError{Duplicates: duplicates}
+		}
+	}
 
-	trxResponse := models.TrxResponse{
-		ID:         transaction.ID,
-		Title:      transaction.Title,
-		Date:       transaction.Date.Format(constants.DateFormat),
-		Amount:     transaction.Amount.InexactFloat64(),
-		BudgetFrom: convertBudgetID(transaction.BudgetFrom),
-		BudgetTo:   convertBudgetID(transaction.BudgetTo),
+	err = s.repository.Create(&transaction)
+	if err != nil {
+		return models.TrxResponse{}, err
 	}
-	return trxResponse, nil
+
+	return newTrxResponse(transaction), nil
 }
 
 func (s TrxService) Patch(c *gin.Context, transaction models.TrxPatchRequest, userID uint) (models.TrxResponse, error) {
@@ -184,32 +248,127 @@ func (s TrxService) Patch(c *gin.Context, transaction models.TrxPatchRequest, us
 		return models.TrxResponse{}, err
 	}
 
-	var amount decimal.Decimal
-	if transaction.Amount != 0 {
-		currAmount := decimal.NewFromFloat(transaction.Amount)
-		amount = currAmount
+	return s.patch(uint(id), transaction, userID)
+}
+
+func (s TrxService) patch(id uint, transaction models.TrxPatchRequest, userID uint) (models.TrxResponse, error) {
+	current, err := editableTrx(s.repository, id, userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	if isReconciled(current) {
+		return models.TrxResponse{}, ErrTrxReconciled
 	}
+	split := current.IsSplit || current.ParentID != nil
 
-	trx := models.Trx{
-		Title:  transaction.Title,
-		Amount: amount,
+	updates := make(map[string]any)
+	if transaction.Title != nil {
+		updates["title"] = *transaction.Title
+	}
+	if transaction.Note != nil {
+		updates["note"] = *transaction.Note
+	}
+	if transaction.Status != nil {
+		// Статус части следует за статусом разделённой транзакции
+		if current.ParentID != nil {
+			return models.TrxResponse{}, errors.New("status of split trx leg is changed via parent trx")
+		}
+		updates["status"] = *transaction.Status
+	}
+	if transaction.Date.Set {
+		if transaction.Date.Value == nil {
+			return models.TrxResponse{}, errors.New("date can't be null")
+		}
+		// Дата части следует за датой разделённой транзакции
+		if current.ParentID != nil {
+			return models.TrxResponse{}, errors.New("date of split trx leg is changed via parent trx")
+		}
+		current.Date, err = time.Parse(constants.DateFormat, *transaction.Date.Value)
+		if err != nil {
+			return models.TrxResponse{}, err
+		}
+		updates["date"] = current.Date
+	}
+	if transaction.Amount.Set {
+		if transaction.Amount.Value == nil {
+			return models.TrxResponse{}, errors.New("amount can't be null")
+		}
+		if split {
+			return models.TrxResponse{}, errors.New("amount of split trx is changed via split")
+		}
+		current.Amount = *transaction.Amount.Value
+		updates["amount"] = current.Amount
+	}
+	if transaction.CategoryID.Set {
+		if transaction.CategoryID.Value != nil {
+			if _, err := s.categoryRepository.Get(*transaction.CategoryID.Value, userID); err != nil {
+				return models.TrxResponse{}, checkExists("CategoryID", err)
+			}
+		}
+		updates["category_id"] = models.NullID(transaction.CategoryID.Value)
+	}
+	if transaction.PayeeID.Set {
+		if transaction.PayeeID.Value != nil {
+			if _, err := s.payeeRepository.Get(*transaction.PayeeID.Value, userID); err != nil {
+				return models.TrxResponse{}, checkExists("PayeeID", err)
+			}
+		}
+		updates["payee_id"] = models.NullID(transaction.PayeeID.Value)
+	}
+
+	budgetsChanged := transaction.BudgetFrom.Set || transaction.BudgetTo.Set
+	if budgetsChanged {
+		if split {
+			return models.TrxResponse{}, errors.New("budgets of split trx are set in legs")
+		}
+		if transaction.BudgetFrom.Set {
+			current.BudgetFrom = models.NullID(transaction.BudgetFrom.Value)
+			updates["budget_from"] = current.BudgetFrom
+		}
+		if transaction.BudgetTo.Set {
+			current.BudgetTo = models.NullID(transaction.BudgetTo.Value)
+			updates["budget_to"] = current.BudgetTo
+		}
+		if err := checkTransfer(s.budgetRepository, userID,
+			models.IDFromNull(current.BudgetFrom), models.IDFromNull(current.BudgetTo)); err != nil {
+			return models.TrxResponse{}, err
+		}
+	}
+
+	// Валюта следует за бюджетами, а сумма зачисления перевода между
+	// валютами пересчитывается вслед за суммой, если не задана явно
+	convertDest := current.DestCurrency != "" && transaction.Amount.Set
+	if budgetsChanged || convertDest || transaction.DestAmount != nil {
+		var currency string
+		if models.IDFromNull(current.BudgetFrom) == nil && models.IDFromNull(current.BudgetTo) == nil {
+			currency = current.Currency
+		}
+		current.DestAmount, current.DestCurrency = nil, ""
+		if err := s.setCurrency(&current, currency, transaction.DestAmount); err != nil {
+			return models.TrxResponse{}, err
+		}
+		updates["currency"] = current.Currency
+		updates["dest_amount"] = current.DestAmount
+		updates["dest_currency"] = current.DestCurrency
 	}
 
-	trxUpdate, err := s.repository.Patch(trx, uint(id), userID)
+	trxUpdate, err := s.repository.Patch(updates, id, userID)
 	if err != nil {
 		return models.TrxResponse{}, err
 	}
 
-	trxResponse := models.TrxResponse{
-		ID:         trxUpdate.ID,
-		Title:      trxUpdate.Title,
-		Date:       trxUpdate.Date.Format(constants.DateFormat),
-		Amount:     trxUpdate.Amount.InexactFloat64(),
-		BudgetFrom: convertBudgetID(trxUpdate.BudgetFrom),
-		BudgetTo:   convertBudgetID(trxUpdate.BudgetTo),
+	if transaction.Tags != nil {
+		tags, err := s.tagRepository.GetOrCreate(normalizeTags(*transaction.Tags), userID)
+		if err != nil {
+			return models.TrxResponse{}, err
+		}
+		if err := s.repository.ReplaceTags(&trxUpdate, tags); err != nil {
+			return models.TrxResponse{}, err
+		}
+		trxUpdate.Tags = tags
 	}
 
-	return trxResponse, nil
+	return newTrxResponse(trxUpdate), nil
 }
 
 func (s TrxService) Delete(c *gin.Context, userID uint) error {
@@ -222,17 +381,544 @@ func (s TrxService) Delete(c *gin.Context, userID uint) error {
 		return err
 	}
 
-	return s.repository.Delete(uint(id), userID)
+	return s.delete(uint(id), userID)
+}
+
+func (s TrxService) delete(id, userID uint) error {
+	trx, err := editableTrx(s.repository, id, userID)
+	if err != nil {
+		return err
+	}
+	// Без части сумма разделённой транзакции не сойдётся
+	if trx.ParentID != nil {
+		return errors.New("leg of split trx can't be deleted, use split")
+	}
+	if isReconciled(trx) {
+		return ErrTrxReconciled
+	}
+
+	// Вложения остаются, пока транзакция не удалена из корзины окончательно
+	return s.repository.Delete(id, userID)
+}
+
+// Снимает блокировку сверенной транзакции, возвращая её в статус cleared
+func (s TrxService) Unlock(c *gin.Context, userID uint) (models.TrxResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+
+	trx, err := editableTrx(s.repository, uint(id), userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	if trx.ParentID != nil {
+		return models.TrxResponse{}, errors.New("leg of split trx is unlocked via parent trx")
+	}
+
+	trx, err = s.repository.Patch(map[string]any{"status": models.TrxStatusCleared}, trx.ID, userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	return newTrxResponse(trx), nil
+}
+
+// Разделение транзакции на части. Существующие части заменяются
+func (s TrxService) Split(c *gin.Context, request models.TrxSplitRequest, userID uint) (models.TrxResponse, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+
+	trx, err := editableTrx(s.repository, uint(id), userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	if trx.ParentID != nil {
+		return models.TrxResponse{}, errors.New("leg of split trx can't be split")
+	}
+	if isReconciled(trx) {
+		return models.TrxResponse{}, ErrTrxReconciled
+	}
+	if err := s.checkLegs(userID, request.Legs); err != nil {
+		return models.TrxResponse{}, err
+	}
+
+	trx.Legs, err = buildTrxLegs(trx, request.Legs)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	if err := s.setLegsCurrency(&trx, trx.Currency); err != nil {
+		return models.TrxResponse{}, err
+	}
+	trx.DestAmount, trx.DestCurrency = nil, ""
+	if err := s.repository.ReplaceLegs(&trx, trx.Legs); err != nil {
+		return models.TrxResponse{}, err
+	}
+	trx.IsSplit = true
+	trx.BudgetFrom, trx.BudgetTo = nil, nil
+
+	return newTrxResponse(trx), nil
 }
 
-func convertBudgetID(budget *sql.NullInt64) *uint {
-	if budget == nil {
+// Определяет валюту транзакции по её бюджетам. Для перевода между бюджетами
+// в разных валютах заполняет сумму зачисления: из запроса или по курсу на дату транзакции
+func (s TrxService) setCurrency(trx *models.Trx, currency string, destAmount *decimal.Decimal) error {
+	var from, to *models.Budget
+	if id := models.IDFromNull(trx.BudgetFrom); id != nil {
+		budget, err := s.budgetRepository.Get(*id, trx.UserID)
+		if err != nil {
+			return err
+		}
+		from = &budget
+	}
+	if id := models.IDFromNull(trx.BudgetTo); id != nil {
+		budget, err := s.budgetRepository.Get(*id, trx.UserID)
+		if err != nil {
+			return err
+		}
+		to = &budget
+	}
+
+	switch {
+	case from != nil:
+		trx.Currency = from.Currency
+	case to != nil:
+		trx.Currency = to.Currency
+	default:
+		trx.Currency = normalizeCurrency(currency)
+	}
+	if currency != "" && normalizeCurrency(currency) != trx.Currency {
+		return fmt.Errorf("trx currency %s doesn't match budget currency %s", currency, trx.Currency)
+	}
+
+	if from == nil || to == nil || from.Currency == to.Currency {
+		if destAmount != nil {
+			return errors.New("dest_amount is only for transfers between currencies")
+		}
+		return nil
+	}
+
+	trx.DestCurrency = to.Currency
+	if destAmount != nil {
+		trx.DestAmount = destAmount
 		return nil
 	}
-	var id uint
-	if budget.Valid {
-		id = uint(budget.Int64)
-		return &id
+	amount, err := s.convertDestAmount(*trx)
+	if err != nil {
+		return err
+	}
+	trx.DestAmount = &amount
+	return nil
+}
+
+// Пересчитывает сумму транзакции в валюту зачисления по курсу на её дату
+func (s TrxService) convertDestAmount(trx models.Trx) (decimal.Decimal, error) {
+	converter := newCurrencyConverter(s.rateRepository, trx.UserID, trx.DestCurrency)
+	amount, err := converter.Convert(trx.Amount, trx.Currency, trx.Date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return amount.Round(2), nil
+}
+
+// Валюта частей определяется их бюджетами и должна быть общей,
+// она же становится валютой разделённой транзакции
+func (s TrxService) setLegsCurrency(trx *models.Trx, currency string) error {
+	for i := range trx.Legs {
+		if err := s.setCurrency(&trx.Legs[i], currency, nil); err != nil {
+			return err
+		}
+		currency = trx.Legs[i].Currency
 	}
+	trx.Currency = currency
 	return nil
 }
+
+// Правила и получатели пользователя для категоризации транзакций
+func (s TrxService) loadRules(userID uint) (ruleSet, error) {
+	rules, err := s.ruleRepository.List(userID)
+	if err != nil {
+		return ruleSet{}, err
+	}
+	payees, err := s.payeeRepository.List(userID)
+	if err != nil {
+		return ruleSet{}, err
+	}
+	return newRuleSet(rules, payees), nil
+}
+
+// Проверяет бюджеты каждой части, нарушения собираются по путям Legs[i]
+func (s TrxService) checkLegs(userID uint, legs []models.TrxLegRequest) error {
+	refErr := make(ReferenceError)
+	for i, leg := range legs {
+		err := checkTransfer(s.budgetRepository, userID, leg.BudgetFrom, leg.BudgetTo)
+		var legErr ReferenceError
+		if errors.As(err, &legErr) {
+			refErr.merge(fmt.Sprintf("Legs[%d].", i), legErr)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(refErr) > 0 {
+		return refErr
+	}
+	return nil
+}
+
+// Части разделённой транзакции. Сумма частей должна совпадать с суммой транзакции
+func buildTrxLegs(parent models.Trx, legRequests []models.TrxLegRequest) ([]models.Trx, error) {
+	total := decimal.Zero
+	legs := make([]models.Trx, 0, len(legRequests))
+	for _, leg := range legRequests {
+		amount := leg.Amount
+		total = total.Add(amount)
+		legs = append(legs, models.Trx{
+			UserID:     parent.UserID,
+			Title:      parent.Title,
+			Note:       leg.Note,
+			Date:       parent.Date,
+			Amount:     amount,
+			BudgetFrom: models.NullID(leg.BudgetFrom),
+			BudgetTo:   models.NullID(leg.BudgetTo),
+			CategoryID: parent.CategoryID,
+			Status:     parent.Status,
+		})
+	}
+	if !total.Equal(parent.Amount) {
+		return nil, fmt.Errorf("legs sum %s doesn't match trx amount %s", total, parent.Amount)
+	}
+	return legs, nil
+}
+
+// Пакетное применение операций. При первой ошибке остальные операции
+// не выполняются, а вызывающий должен откатить транзакцию БД
+func (s TrxService) Bulk(request models.TrxBulkRequest, userID uint) models.TrxBulkResponse {
+	resp := models.TrxBulkResponse{
+		Applied: true,
+		Results: make([]models.TrxBulkResult, len(request.Operations)),
+	}
+
+	for i, op := range request.Operations {
+		result := models.TrxBulkResult{Index: i, Op: op.Op}
+		if resp.FailedIndex != nil {
+			result.Status = models.BulkOpSkipped
+			resp.Results[i] = result
+			continue
+		}
+
+		trx, err := s.applyBulkOperation(op, userID)
+		if err != nil {
+			result.Status = models.BulkOpFailed
+			var refErr ReferenceError
+			if vErrs := validators.ParseValidationErrors(err); vErrs != nil {
+				result.Error = vErrs
+			} else if errors.As(err, &refErr) {
+				result.Error = refErr
+			} else {
+				result.Error = err.Error()
+			}
+			failed := i
+			resp.Applied = false
+			resp.FailedIndex = &failed
+		} else {
+			result.Status = models.BulkOpApplied
+			result.Trx = trx
+		}
+		resp.Results[i] = result
+	}
+
+	return resp
+}
+
+func (s TrxService) applyBulkOperation(op models.TrxBulkOperation, userID uint) (*models.TrxResponse, error) {
+	switch op.Op {
+	case models.BulkOpCreate:
+		var request models.TrxRequest
+		if err := decodeBulkData(op.Data, &request); err != nil {
+			return nil, err
+		}
+		trx, err := s.Create(&request, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &trx, nil
+	case models.BulkOpPatch:
+		var request models.TrxPatchRequest
+		if err := decodeBulkData(op.Data, &request); err != nil {
+			return nil, err
+		}
+		trx, err := s.patch(op.ID, request, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &trx, nil
+	case models.BulkOpDelete:
+		return nil, s.delete(op.ID, userID)
+	}
+	return nil, fmt.Errorf("unknown operation: %s", op.Op)
+}
+
+func decodeBulkData(data json.RawMessage, request any) error {
+	if len(data) == 0 {
+		return errors.New("data is required")
+	}
+	if err := json.Unmarshal(data, request); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	return validators.IsValid(request)
+}
+
+// Транзакция, которую пользователь может изменять: своя или транзакция
+// общего бюджета, где он не наблюдатель
+func editableTrx(trxs repository.TrxRepository, id, userID uint) (models.Trx, error) {
+	if _, err := trxs.Get(id, userID); err != nil {
+		return models.Trx{}, err
+	}
+	trx, err := trxs.Get(id, userID, models.BudgetWriteRoles...)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Trx{}, ErrBudgetForbidden
+	}
+	return trx, err
+}
+
+// Сверенная транзакция или разделённая транзакция со сверенной частью
+func isReconciled(trx models.Trx) bool {
+	if trx.Status == models.TrxStatusReconciled {
+		return true
+	}
+	for _, leg := range trx.Legs {
+		if leg.Status == models.TrxStatusReconciled {
+			return true
+		}
+	}
+	return false
+}
+
+// Колонки выгрузки транзакций
+var trxExportColumns = []export.Column{
+	{Name: "id", Numeric: true},
+	{Name: "date"},
+	{Name: "title"},
+	{Name: "note"},
+	{Name: "amount", Numeric: true},
+	{Name: "currency"},
+	{Name: "dest_amount", Numeric: true},
+	{Name: "dest_currency"},
+	{Name: "budget_from", Numeric: true},
+	{Name: "budget_to", Numeric: true},
+	{Name: "category_id", Numeric: true},
+	{Name: "payee_id", Numeric: true},
+	{Name: "status"},
+	{Name: "type"},
+	{Name: "tags"},
+}
+
+// Выгрузка транзакций по тем же фильтрам, что и List.
+// Строки пишутся в w по мере чтения из БД
+func (s TrxService) Export(c *gin.Context, userID uint, format export.Format, w io.Writer) error {
+	filter, err := parseTrxFilter(c)
+	if err != nil {
+		return err
+	}
+
+	writer, err := export.NewWriter(format, w, trxExportColumns)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repository.Each(userID, filter, func(trx models.Trx) error {
+		return writer.WriteRow([]string{
+			strconv.FormatUint(uint64(trx.ID), 10),
+			trx.Date.Format(constants.DateFormat),
+			trx.Title,
+			trx.Note,
+			trx.Amount.String(),
+			trx.Currency,
+			formatNullDecimal(trx.DestAmount),
+			trx.DestCurrency,
+			formatNullID(trx.BudgetFrom),
+			formatNullID(trx.BudgetTo),
+			formatNullID(trx.CategoryID),
+			formatNullID(trx.PayeeID),
+			string(trx.Status),
+			string(trx.Type),
+			strings.Join(convertTagsToTitles(trx.Tags), ","),
+		})
+	}); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func formatNullDecimal(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func formatNullID(id *sql.NullInt64) string {
+	if id == nil || !id.Valid {
+		return ""
+	}
+	return strconv.FormatInt(id.Int64, 10)
+}
+
+func trxSortValue(trx models.Trx, sort string) any {
+	switch sort {
+	case models.SortRelevance:
+		return decimal.NewFromFloat(trx.Rank)
+	case "amount":
+		return trx.Amount
+	case "created_at":
+		return trx.CreatedAt
+	default:
+		return trx.Date
+	}
+}
+
+func newTrxResponse(trx models.Trx) models.TrxResponse {
+	resp := models.TrxResponse{
+		ID:         trx.ID,
+		Title:      trx.Title,
+		Note:       trx.Note,
+		Date:       trx.Date.Format(constants.DateFormat),
+		Amount:     trx.Amount,
+		BudgetFrom: models.IDFromNull(trx.BudgetFrom),
+		BudgetTo:   models.IDFromNull(trx.BudgetTo),
+		CategoryID: models.IDFromNull(trx.CategoryID),
+		Tags:       convertTagsToTitles(trx.Tags),
+		PayeeID:    models.IDFromNull(trx.PayeeID),
+		Status:     trx.Status,
+		Type:       trx.Type,
+		Currency:   trx.Currency,
+		Legs:       newTrxLegResponses(trx.Legs),
+		CreatedBy:  trx.UserID,
+	}
+	if trx.DestAmount != nil {
+		resp.DestAmount = trx.DestAmount
+		resp.DestCurrency = trx.DestCurrency
+	}
+	return resp
+}
+
+func newTrxLegResponses(legs []models.Trx) []models.TrxLegResponse {
+	if len(legs) == 0 {
+		return nil
+	}
+	resp := make([]models.TrxLegResponse, 0, len(legs))
+	for _, leg := range legs {
+		legResp := models.TrxLegResponse{
+			ID:         leg.ID,
+			Note:       leg.Note,
+			Amount:     leg.Amount,
+			BudgetFrom: models.IDFromNull(leg.BudgetFrom),
+			BudgetTo:   models.IDFromNull(leg.BudgetTo),
+			Status:     leg.Status,
+			Type:       leg.Type,
+		}
+		if leg.DestAmount != nil {
+			legResp.DestAmount = leg.DestAmount
+			legResp.DestCurrency = leg.DestCurrency
+		}
+		resp = append(resp, legResp)
+	}
+	return resp
+}
+
+// Разбор фильтров списка транзакций из query параметров
+func parseTrxFilter(c *gin.Context) (models.TrxFilter, error) {
+	var filter models.TrxFilter
+
+	if amountMinStr := c.Query("amount_min"); amountMinStr != "" {
+		amountMin, err := decimal.NewFromString(amountMinStr)
+		if err != nil {
+			return models.TrxFilter{}, err
+		}
+		filter.MinAmount = amountMin
+	}
+	if amountMaxStr := c.Query("amount_max"); amountMaxStr != "" {
+		amountMax, err := decimal.NewFromString(amountMaxStr)
+		if err != nil {
+			return models.TrxFilter{}, err
+		}
+		filter.MaxAmount = amountMax
+	}
+
+	if dateFromStr := c.Query("date_from"); dateFromStr != "" {
+		dateFrom, err := time.Parse(constants.DateFormat, dateFromStr)
+		if err != nil {
+			return models.TrxFilter{}, err
+		}
+		filter.DateFrom = dateFrom
+	}
+
+	if dateToStr := c.Query("date_to"); dateToStr != "" {
+		dateTo, err := time.Parse(constants.DateFormat, dateToStr)
+		if err != nil {
+			return models.TrxFilter{}, err
+		}
+		filter.DateTo = dateTo
+	}
+
+	filter.Query = strings.TrimSpace(c.Query("q"))
+
+	// category_id=1,2,null
+	if categoryStr := c.Query("category_id"); categoryStr != "" {
+		for _, v := range strings.Split(categoryStr, ",") {
+			v = strings.TrimSpace(v)
+			if v == "null" {
+				filter.NoCategory = true
+				continue
+			}
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return models.TrxFilter{}, err
+			}
+			filter.CategoryIDs = append(filter.CategoryIDs, uint(id))
+		}
+	}
+
+	// tags=any:a,b или tags=all:a,b
+	if tagsStr := c.Query("tags"); tagsStr != "" {
+		filter.TagsMatch = models.TagsMatchAny
+		if mode, list, found := strings.Cut(tagsStr, ":"); found {
+			switch models.TagsMatch(mode) {
+			case models.TagsMatchAny, models.TagsMatchAll:
+				filter.TagsMatch = models.TagsMatch(mode)
+				tagsStr = list
+			}
+		}
+		filter.Tags = normalizeTags(strings.Split(tagsStr, ","))
+	}
+
+	// status=pending,cleared
+	if statusStr := c.Query("status"); statusStr != "" {
+		for _, v := range strings.Split(statusStr, ",") {
+			status := models.TrxStatus(strings.TrimSpace(v))
+			switch status {
+			case models.TrxStatusPending, models.TrxStatusCleared, models.TrxStatusReconciled:
+				filter.Statuses = append(filter.Statuses, status)
+			default:
+				return models.TrxFilter{}, fmt.Errorf("unknown status: %s", status)
+			}
+		}
+	}
+
+	// type=income,expense
+	if typeStr := c.Query("type"); typeStr != "" {
+		for _, v := range strings.Split(typeStr, ",") {
+			trxType := models.TrxType(strings.TrimSpace(v))
+			switch trxType {
+			case models.TrxTypeIncome, models.TrxTypeExpense, models.TrxTypeTransfer, models.TrxTypeSplit:
+				filter.Types = append(filter.Types, trxType)
+			default:
+				return models.TrxFilter{}, fmt.Errorf("unknown type: %s", trxType)
+			}
+		}
+	}
+
+	return filter, nil
+}
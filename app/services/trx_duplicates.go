@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"finapp/constants"
+	"finapp/models"
+)
+
+const (
+	// Интервал дат по умолчанию, в котором транзакции считаются дубликатами
+	duplicateWindowDays = 3
+	// Минимальное сходство названий дубликатов
+	duplicateMinSimilarity = 0.5
+)
+
+// Похожие транзакции, найденные при создании с проверкой дубликатов. Отдаётся клиенту как 409
+type DuplicateError struct {
+	Duplicates []models.TrxResponse
+}
+
+func (e DuplicateError) Error() string {
+	return fmt.Sprintf("trx looks like a duplicate of %d existing trx", len(e.Duplicates))
+}
+
+// Пары вероятных дубликатов за период, кроме отклонённых пользователем.
+// window задаёт максимальное расстояние между датами в днях
+func (s TrxService) Duplicates(c *gin.Context, userID uint) ([]models.TrxDuplicate, error) {
+	var dateFrom, dateTo time.Time
+	if dateFromStr := c.Query("date_from"); dateFromStr != "" {
+		date, err := time.Parse(constants.DateFormat, dateFromStr)
+		if err != nil {
+			return nil, err
+		}
+		dateFrom = date
+	}
+	if dateToStr := c.Query("date_to"); dateToStr != "" {
+		date, err := time.Parse(constants.DateFormat, dateToStr)
+		if err != nil {
+			return nil, err
+		}
+		dateTo = date
+	}
+	if !dateTo.IsZero() && dateTo.Before(dateFrom) {
+		return nil, errors.New("date_from time goes after date_to")
+	}
+
+	window := duplicateWindowDays
+	if windowStr := c.Query("window"); windowStr != "" {
+		days, err := strconv.Atoi(windowStr)
+		if err != nil {
+			return nil, err
+		}
+		if days < 0 {
+			return nil, errors.New("window must not be negative")
+		}
+		window = days
+	}
+
+	trxs, err := s.repository.ListForDuplicates(userID, dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+	dismissals, err := s.repository.ListDismissedDuplicates(userID)
+	if err != nil {
+		return nil, err
+	}
+	dismissed := make(map[[2]uint]bool, len(dismissals))
+	for _, d := range dismissals {
+		dismissed[[2]uint{d.TrxID, d.OtherID}] = true
+	}
+
+	// Кандидаты сравниваются только внутри группы с одинаковыми суммой и бюджетами,
+	// транзакции в группе идут по дате
+	groups := make(map[string][]models.Trx)
+	var resp []models.TrxDuplicate
+	for _, trx := range trxs {
+		key := strings.Join([]string{trx.Amount.String(), formatNullID(trx.BudgetFrom), formatNullID(trx.BudgetTo)}, "|")
+		for _, prev := range groups[key] {
+			days := daysApart(prev.Date, trx.Date)
+			if days > window || dismissed[duplicatePair(prev.ID, trx.ID)] {
+				continue
+			}
+			similarity := titleSimilarity(prev.Title, trx.Title)
+			if similarity < duplicateMinSimilarity {
+				continue
+			}
+			resp = append(resp, models.TrxDuplicate{
+				Trx:        newTrxResponse(prev),
+				Duplicate:  newTrxResponse(trx),
+				Similarity: similarity,
+				DaysApart:  days,
+			})
+		}
+		groups[key] = append(groups[key], trx)
+	}
+	return resp, nil
+}
+
+// Объединяет две транзакции-дубликата в одну
+func (s TrxService) MergeDuplicates(request models.TrxDuplicateMergeRequest, userID uint) (models.TrxResponse, error) {
+	keep, err := editableTrx(s.repository, request.KeepID, userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	remove, err := editableTrx(s.repository, request.RemoveID, userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	if keep.ParentID != nil || remove.ParentID != nil {
+		return models.TrxResponse{}, errors.New("leg of split trx can't be merged")
+	}
+	if isReconciled(keep) || isReconciled(remove) {
+		return models.TrxResponse{}, ErrTrxReconciled
+	}
+
+	updates := make(map[string]any)
+	if keep.Note == "" && remove.Note != "" {
+		updates["note"] = remove.Note
+	}
+	if !isValidID(keep.CategoryID) && isValidID(remove.CategoryID) {
+		updates["category_id"] = remove.CategoryID
+	}
+	if !isValidID(keep.PayeeID) && isValidID(remove.PayeeID) {
+		updates["payee_id"] = remove.PayeeID
+	}
+
+	tags := keep.Tags
+	for _, tag := range remove.Tags {
+		if !hasTag(tags, tag.ID) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) != len(keep.Tags) {
+		if err := s.repository.ReplaceTags(&keep, tags); err != nil {
+			return models.TrxResponse{}, err
+		}
+	}
+
+	if err := s.attachmentRepository.MoveToTrx(remove.ID, keep.ID); err != nil {
+		return models.TrxResponse{}, err
+	}
+	// Повторный импорт той же записи выписки по-прежнему пропускается
+	if err := s.repository.MoveImported(remove.ID, keep.ID, userID); err != nil {
+		return models.TrxResponse{}, err
+	}
+	if err := s.delete(remove.ID, userID); err != nil {
+		return models.TrxResponse{}, err
+	}
+
+	trx, err := s.repository.Patch(updates, keep.ID, userID)
+	if err != nil {
+		return models.TrxResponse{}, err
+	}
+	return newTrxResponse(trx), nil
+}
+
+// Отмечает пару транзакций как не дубликаты, чтобы она больше не попадала в список
+func (s TrxService) DismissDuplicate(request models.TrxDuplicateDismissRequest, userID uint) error {
+	for _, id := range []uint{request.TrxID, request.DuplicateID} {
+		if _, err := s.repository.Get(id, userID); err != nil {
+			return err
+		}
+	}
+
+	pair := duplicatePair(request.TrxID, request.DuplicateID)
+	return s.repository.DismissDuplicate(&models.DuplicateDismissal{
+		UserID:  userID,
+		TrxID:   pair[0],
+		OtherID: pair[1],
+	})
+}
+
+// Похожие на новую транзакцию существующие транзакции
+func (s TrxService) findDuplicates(trx models.Trx) ([]models.TrxResponse, error) {
+	window := duplicateWindowDays * 24 * time.Hour
+	similar, err := s.repository.ListSimilar(trx, trx.Date.Add(-window), trx.Date.Add(window))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []models.TrxResponse
+	for _, v := range similar {
+		if titleSimilarity(v.Title, trx.Title) >= duplicateMinSimilarity {
+			resp = append(resp, newTrxResponse(v))
+		}
+	}
+	return resp, nil
+}
+
+func duplicatePair(a, b uint) [2]uint {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint{a, b}
+}
+
+func daysApart(a, b time.Time) int {
+	days := int(b.Sub(a).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+// Сходство названий по словам без учёта регистра, цифр и знаков препинания:
+// номера карт и чеков в названиях банковских выписок обычно различаются
+func titleSimilarity(a, b string) float64 {
+	wordsA, wordsB := titleWords(a), titleWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		if len(wordsA) == len(wordsB) {
+			return 1
+		}
+		return 0
+	}
+
+	joinedA, joinedB := strings.Join(wordsA, " "), strings.Join(wordsB, " ")
+	if joinedA == joinedB {
+		return 1
+	}
+	if strings.Contains(joinedA, joinedB) || strings.Contains(joinedB, joinedA) {
+		return 0.8
+	}
+
+	set := make(map[string]bool, len(wordsA))
+	for _, word := range wordsA {
+		set[word] = true
+	}
+	var common int
+	union := len(set)
+	seen := make(map[string]bool, len(wordsB))
+	for _, word := range wordsB {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		if set[word] {
+			common++
+		} else {
+			union++
+		}
+	}
+	return float64(common) / float64(union)
+}
+
+func titleWords(title string) []string {
+	return strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
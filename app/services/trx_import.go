@@ -0,0 +1,165 @@
+package services
+
+import (
+	"database/sql"
+	"io"
+	"slices"
+
+	"finapp/lib/statements"
+	"finapp/models"
+)
+
+// Импорт выписки (CSV, OFX или QIF) в транзакции бюджета.
+// При dry run транзакции не создаются, но отчёт строится полностью
+func (s TrxService) Import(file io.Reader, request models.TrxImportRequest, userID uint) (models.TrxImportResponse, error) {
+	budget, err := s.budgetRepository.Get(request.BudgetID, userID)
+	if err != nil {
+		return models.TrxImportResponse{}, err
+	}
+	role, err := s.budgetRepository.Role(budget.ID, userID)
+	if err != nil {
+		return models.TrxImportResponse{}, err
+	}
+	// Наблюдатель общего бюджета не может импортировать в него выписку
+	if !slices.Contains(models.BudgetWriteRoles, role) {
+		return models.TrxImportResponse{}, ReferenceError{"BudgetID": "editable"}
+	}
+
+	var entries []statements.Entry
+	switch request.Format {
+	case models.ImportFormatOFX:
+		entries, err = statements.ParseOFX(file)
+	case models.ImportFormatQIF:
+		entries, err = statements.ParseQIF(file, request.DateFormat)
+	default:
+		dateFormat := request.DateFormat
+		if dateFormat == "" {
+			dateFormat = "DD.MM.YYYY"
+		}
+		entries, err = statements.ParseCSV(file, statements.CSVMapping{
+			DateColumn:       request.DateColumn,
+			AmountColumn:     request.AmountColumn,
+			TitleColumn:      request.TitleColumn,
+			DateFormat:       dateFormat,
+			Delimiter:        request.Delimiter,
+			DecimalSeparator: request.DecimalSeparator,
+			HasHeader:        request.HasHeader,
+		})
+	}
+	if err != nil {
+		return models.TrxImportResponse{}, err
+	}
+
+	return s.importEntries(entries, request, budget.Currency, userID)
+}
+
+// Суммы выписки считаются в валюте бюджета
+func (s TrxService) importEntries(entries []statements.Entry, request models.TrxImportRequest, currency string, userID uint) (models.TrxImportResponse, error) {
+	resp := models.TrxImportResponse{
+		DryRun: request.DryRun,
+		Rows:   make([]models.TrxImportRowReport, 0, len(entries)),
+	}
+
+	// Идентификаторы записей, встреченные в этом же файле
+	seen := make(map[string]bool)
+
+	rules, err := s.loadRules(userID)
+	if err != nil {
+		return models.TrxImportResponse{}, err
+	}
+
+	for _, entry := range entries {
+		row := models.TrxImportRowReport{Row: entry.Line}
+
+		if entry.Err == nil && entry.ExternalID != "" {
+			imported, err := s.repository.IsImported(userID, request.BudgetID, entry.ExternalID)
+			if err != nil {
+				return models.TrxImportResponse{}, err
+			}
+			if imported || seen[entry.ExternalID] {
+				row.Status = models.ImportRowSkipped
+				row.Error = "already imported"
+				resp.Skipped++
+				resp.Rows = append(resp.Rows, row)
+				continue
+			}
+			seen[entry.ExternalID] = true
+		}
+
+		switch {
+		case entry.Err != nil:
+			row.Status = models.ImportRowInvalid
+			row.Error = entry.Err.Error()
+			resp.Invalid++
+		case entry.Empty:
+			row.Status = models.ImportRowSkipped
+			row.Error = "empty row"
+			resp.Skipped++
+		case entry.Amount.IsZero():
+			row.Status = models.ImportRowSkipped
+			row.Error = "zero amount"
+			resp.Skipped++
+		default:
+			trx := newImportedTrx(entry, request, userID)
+			trx.Currency = currency
+			rules.Apply(&trx, false)
+			if !request.DryRun {
+				if err := s.repository.Create(&trx); err != nil {
+					return models.TrxImportResponse{}, err
+				}
+				if entry.ExternalID != "" {
+					if err := s.repository.MarkImported(&models.ImportedEntry{
+						UserID:     userID,
+						BudgetID:   request.BudgetID,
+						ExternalID: entry.ExternalID,
+						TrxID:      trx.ID,
+					}); err != nil {
+						return models.TrxImportResponse{}, err
+					}
+				}
+			}
+			trxResponse := newTrxResponse(trx)
+			row.Status = models.ImportRowCreated
+			row.Trx = &trxResponse
+			resp.Created++
+		}
+
+		resp.Rows = append(resp.Rows, row)
+	}
+
+	return resp, nil
+}
+
+// Строит транзакцию по записи выписки: расход списывается с бюджета, доход зачисляется на него
+func newImportedTrx(entry statements.Entry, request models.TrxImportRequest, userID uint) models.Trx {
+	budget := &sql.NullInt64{Int64: int64(request.BudgetID), Valid: true}
+
+	var expense bool
+	switch request.Sign {
+	case models.SignInverted:
+		expense = entry.Amount.IsPositive()
+	case models.SignExpense:
+		expense = true
+	case models.SignIncome:
+		expense = false
+	default:
+		expense = entry.Amount.IsNegative()
+	}
+
+	trx := models.Trx{
+		UserID:     userID,
+		Title:      entry.Title,
+		Date:       entry.Date,
+		Amount:     entry.Amount.Abs(),
+		BudgetFrom: &sql.NullInt64{},
+		BudgetTo:   &sql.NullInt64{},
+		CategoryID: &sql.NullInt64{},
+		Status:     models.TrxStatusCleared,
+	}
+	if expense {
+		trx.BudgetFrom, trx.Type = budget, models.TrxTypeExpense
+	} else {
+		trx.BudgetTo, trx.Type = budget, models.TrxTypeIncome
+	}
+	return trx
+}